@@ -0,0 +1,184 @@
+package routix
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// AccessLogEntry describes one completed request.
+type AccessLogEntry struct {
+	Time      time.Time     `json:"time"`
+	Method    string        `json:"method"`
+	Path      string        `json:"path"`
+	Status    int           `json:"status"`
+	Latency   time.Duration `json:"latency"`
+	Size      int           `json:"size"`
+	IP        string        `json:"ip"`
+	RequestID string        `json:"request_id,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// AccessLogger receives an entry for every request handled by AccessLog.
+// Implementations ship entries wherever they like; formatting is theirs.
+type AccessLogger interface {
+	Log(entry AccessLogEntry)
+}
+
+// AccessLoggerFunc adapts an ordinary function to AccessLogger.
+type AccessLoggerFunc func(entry AccessLogEntry)
+
+func (f AccessLoggerFunc) Log(entry AccessLogEntry) { f(entry) }
+
+// JSONAccessLogger writes entries as JSON lines to an io.Writer.
+type JSONAccessLogger struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONAccessLogger returns an AccessLogger writing JSON lines to w.
+func NewJSONAccessLogger(w io.Writer) *JSONAccessLogger {
+	return &JSONAccessLogger{enc: json.NewEncoder(w)}
+}
+
+func (l *JSONAccessLogger) Log(entry AccessLogEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.enc.Encode(entry)
+}
+
+// AccessLog returns a middleware that reports every request to logger.
+// A nil logger writes JSON lines to stdout.
+func AccessLog(logger AccessLogger) Middleware {
+	if logger == nil {
+		logger = NewJSONAccessLogger(os.Stdout)
+	}
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			start := time.Now()
+			err := next(c)
+
+			entry := AccessLogEntry{
+				Time:      start,
+				Method:    c.Request.Method,
+				Path:      c.Request.URL.Path,
+				Status:    responseStatus(c, err),
+				Latency:   time.Since(start),
+				Size:      c.Writer.Size(),
+				IP:        c.ClientIP(),
+				RequestID: requestIDOf(c),
+			}
+			if err != nil {
+				entry.Error = err.Error()
+			}
+
+			logger.Log(entry)
+			return err
+		}
+	}
+}
+
+// responseStatus is the status a request ends with. When a handler returns
+// an error without writing, the router writes the error response after
+// middleware returns, so derive the status it will use.
+func responseStatus(c *Context, err error) int {
+	if err != nil && !c.Writer.written {
+		return GetHTTPStatusCode(err)
+	}
+	return c.Status()
+}
+
+// requestIDKey holds the id chosen by RequestID in the context values.
+const requestIDKey = "routix.request_id"
+
+// requestIDOf returns the id chosen by RequestID, else one set on the
+// response by other middleware, falling back to one supplied by the client.
+func requestIDOf(c *Context) string {
+	if id, ok := c.Get(requestIDKey); ok {
+		return id.(string)
+	}
+	if id := c.Response.Header().Get("X-Request-ID"); id != "" {
+		return id
+	}
+	return c.Request.Header.Get("X-Request-ID")
+}
+
+// RequestID returns a middleware that gives every request an id, reusing
+// the client's X-Request-ID when present, and echoes it in the response.
+func RequestID() Middleware {
+	return RequestIDWithOptions(RequestIDOptions{})
+}
+
+// RequestIDOptions configures RequestIDWithOptions for infrastructures that
+// use other correlation headers.
+type RequestIDOptions struct {
+	// Inbound lists the request headers checked for an existing id; the
+	// first one present wins. Defaults to X-Request-ID.
+	Inbound []string
+	// Outbound is the response header the id is written to. Defaults to
+	// X-Request-ID.
+	Outbound string
+	// Generate creates an id when the request carries none. Defaults to
+	// GenerateID.
+	Generate func() string
+}
+
+// RequestIDWithOptions is RequestID with configurable header names. The id
+// is also what AccessLog, SlowLog and PropagateHeaders report.
+//
+//	r.Use(routix.RequestIDWithOptions(routix.RequestIDOptions{
+//		Inbound:  []string{"X-Correlation-ID", "X-Amzn-Trace-Id"},
+//		Outbound: "X-Correlation-ID",
+//	}))
+func RequestIDWithOptions(opts RequestIDOptions) Middleware {
+	if len(opts.Inbound) == 0 {
+		opts.Inbound = []string{"X-Request-ID"}
+	}
+	if opts.Outbound == "" {
+		opts.Outbound = "X-Request-ID"
+	}
+	if opts.Generate == nil {
+		opts.Generate = GenerateID
+	}
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			var id string
+			for _, name := range opts.Inbound {
+				if id = c.Request.Header.Get(name); id != "" {
+					break
+				}
+			}
+			if id == "" {
+				id = opts.Generate()
+			}
+			c.Set(requestIDKey, id)
+			c.Response.Header().Set(opts.Outbound, id)
+			return next(c)
+		}
+	}
+}
+
+// SlowLog returns a middleware that logs a warning through the standard
+// log package for requests taking longer than threshold. It complements
+// AccessLog rather than replacing it.
+func SlowLog(threshold time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			start := time.Now()
+			err := next(c)
+
+			if latency := time.Since(start); latency > threshold {
+				id := requestIDOf(c)
+				if id == "" {
+					id = "-"
+				}
+				log.Printf("routix: slow request %s %s took %v (threshold %v, request_id=%s, ip=%s)",
+					c.Request.Method, c.Request.URL.Path, latency, threshold, id, c.ClientIP())
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package routix
+
+import "net/http"
+
+// WrapHandler adapts a standard library http.Handler into a Handler, so
+// existing handlers such as http.FileServer or promhttp.Handler() can be
+// mounted on a route.
+func WrapHandler(h http.Handler) Handler {
+	return func(c *Context) error {
+		h.ServeHTTP(c.Response, c.Request)
+		return nil
+	}
+}
+
+// WrapHandlerFunc adapts an http.HandlerFunc into a Handler.
+func WrapHandlerFunc(fn http.HandlerFunc) Handler {
+	return WrapHandler(fn)
+}
+
+// WrapMiddleware adapts standard library middleware into a Middleware. A
+// request or response writer the middleware passes on is used for the rest
+// of the chain, so context values and writer wrappers it adds are seen by
+// later handlers. Middleware that doesn't call the next handler ends the
+// chain, as it would in net/http.
+func WrapMiddleware(mw func(http.Handler) http.Handler) Middleware {
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			var err error
+			response := c.Response
+			defer func() { c.Response = response }()
+
+			mw(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				c.Request = req
+				c.Response = w
+				err = next(c)
+			})).ServeHTTP(c.Response, c.Request)
+			return err
+		}
+	}
+}
@@ -0,0 +1,451 @@
+package routix
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Codec marshals values for a wire format routix does not implement itself,
+// so formats like MessagePack don't become hard dependencies.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var msgPackCodec Codec
+
+// SetMsgPackCodec registers the codec used by Context.MsgPack and by Bind for
+// application/msgpack bodies.
+func SetMsgPackCodec(codec Codec) {
+	msgPackCodec = codec
+}
+
+// MsgPack writes data encoded with the registered MessagePack codec.
+func (c *Context) MsgPack(status int, data interface{}) error {
+	if msgPackCodec == nil {
+		return fmt.Errorf("routix: no msgpack codec registered")
+	}
+	body, err := msgPackCodec.Marshal(data)
+	if err != nil {
+		return err
+	}
+	c.Response.Header().Set("Content-Type", "application/msgpack")
+	c.Response.WriteHeader(status)
+	_, err = c.Response.Write(body)
+	return err
+}
+
+// Decoder decodes a request body into v.
+type Decoder func(r io.Reader, v interface{}) error
+
+// RegisterDecoder makes Bind decode bodies of the given media type (CSV,
+// TOML, ...) with fn. Registered decoders take precedence over the
+// built-in ones.
+func (r *Router) RegisterDecoder(contentType string, fn Decoder) *Router {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.decoders == nil {
+		r.decoders = make(map[string]Decoder)
+	}
+	r.decoders[strings.ToLower(contentType)] = fn
+	return r
+}
+
+func (r *Router) decoder(contentType string) (Decoder, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	fn, ok := r.decoders[contentType]
+	return fn, ok
+}
+
+// Bind decodes the request body into v, choosing the decoder from the
+// Content-Type header. Decoders registered with Router.RegisterDecoder are
+// tried first, then the built-in JSON, MessagePack and multipart support.
+// Multipart text fields are matched by the form tag and uploaded files by
+// the file tag, onto *multipart.FileHeader or []*multipart.FileHeader
+// fields.
+func (c *Context) Bind(v interface{}) error {
+	ct := c.Request.Header.Get("Content-Type")
+	mt := mediaType(ct)
+	if c.router != nil {
+		if fn, ok := c.router.decoder(mt); ok {
+			return fn(c.bodyReader(), v)
+		}
+	}
+
+	switch mt {
+	case "application/json":
+		// Reuse the map when BodyMap or AutoParseBody has decoded it.
+		if c.Body != nil {
+			return FromMap(c.Body, v)
+		}
+		return c.decodeJSON(v)
+	case "application/msgpack", "application/x-msgpack":
+		if msgPackCodec == nil {
+			return fmt.Errorf("routix: no msgpack codec registered")
+		}
+		data, err := io.ReadAll(c.bodyReader())
+		if err != nil {
+			return err
+		}
+		return msgPackCodec.Unmarshal(data, v)
+	case "application/x-www-form-urlencoded":
+		form, err := c.ParseForm()
+		if err != nil {
+			return err
+		}
+		return bindValues(form, v, "form")
+	case "multipart/form-data":
+		if err := c.Request.ParseMultipartForm(defaultMultipartMemory); err != nil {
+			return err
+		}
+		if err := bindValues(c.Request.MultipartForm.Value, v, "form"); err != nil {
+			return err
+		}
+		return bindFiles(c.Request.MultipartForm.File, v)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedContentType, ct)
+	}
+}
+
+// BindValidated binds the request body into a T, then checks it against both
+// its validate struct tags and schema. All failures are aggregated into a
+// single 400 *Error wrapping ValidationErrors. A nil schema skips the schema
+// check.
+func BindValidated[T any](c *Context, schema Schema) (T, error) {
+	var v T
+	if err := c.Bind(&v); err != nil {
+		return v, BadRequest("Invalid request body", err)
+	}
+
+	var errs ValidationErrors
+
+	validator := NewValidator()
+	if !validator.Validate(&v) {
+		errs = append(errs, convertToValidationErrors(validator.Errors())...)
+	}
+
+	if schema != nil {
+		raw := any(c.BodyMap())
+		if c.Body == nil {
+			m, err := ToMap(v)
+			if err != nil {
+				return v, BadRequest("Invalid request body", err)
+			}
+			raw = m
+		}
+		if err := schema.Validate(raw); err != nil {
+			errs = append(errs, NewValidationError("body", err.Error()))
+		}
+	}
+
+	if len(errs) > 0 {
+		return v, BadRequest("Validation failed", errs)
+	}
+	return v, nil
+}
+
+// BindAndValidate binds the request body into v and checks its validate
+// tags, returning failures as a field-to-message map, the shape form
+// libraries expect. Fields are keyed by their JSON names; problems with
+// the body as a whole are keyed "body". Only the first failure per field
+// is kept. ok is true when the map is empty.
+func (c *Context) BindAndValidate(v interface{}) (errs map[string]string, ok bool) {
+	errs = make(map[string]string)
+	if err := c.Bind(v); err != nil {
+		if ve, isField := err.(*ValidationError); isField {
+			errs[ve.Field] = ve.Message
+		} else {
+			errs["body"] = err.Error()
+		}
+		return errs, false
+	}
+
+	validator := NewValidator()
+	if !validator.Validate(v) {
+		for _, e := range validator.Errors() {
+			field := e.Field
+			if field == "" {
+				field = "body"
+			}
+			if _, seen := errs[field]; !seen {
+				errs[field] = e.Message
+			}
+		}
+	}
+	return errs, len(errs) == 0
+}
+
+// ShouldBind is Bind under the name used alongside MustBind: it only
+// returns the error and leaves responding to the caller.
+func (c *Context) ShouldBind(v interface{}) error {
+	return c.Bind(v)
+}
+
+// ShouldBindJSON decodes the body as JSON whatever its Content-Type.
+func (c *Context) ShouldBindJSON(v interface{}) error {
+	if c.Body != nil {
+		return FromMap(c.Body, v)
+	}
+	return c.decodeJSON(v)
+}
+
+// ShouldBindQuery decodes the URL query into the struct pointed to by v.
+// Fields are matched by their query tag, then their json tag, then their
+// name. Repeated parameters fill slice fields.
+func (c *Context) ShouldBindQuery(v interface{}) error {
+	return bindValues(c.Request.URL.Query(), v, "query")
+}
+
+// BindUri decodes the path parameters into the struct pointed to by v,
+// matching fields by their uri tag, then their json tag, then their name.
+// It eases moving handlers over from Gin; c.Param and c.Params read the
+// same values.
+func (c *Context) BindUri(v interface{}) error {
+	values := make(map[string][]string, len(c.Params))
+	for name, value := range c.Params {
+		values[name] = []string{value}
+	}
+	return bindValues(values, v, "uri")
+}
+
+// BindQuery binds the URL query like ShouldBindQuery, filling parameters
+// that are absent from their default tag, then checks the validate tags.
+// Unparseable or invalid input yields a 422 *Error wrapping
+// ValidationErrors.
+//
+//	type listParams struct {
+//		Page  int    `query:"page" default:"1" validate:"min=1"`
+//		Limit int    `query:"limit" default:"20" validate:"min=1,max=100"`
+//		Sort  string `query:"sort" default:"created_at" validate:"enum=created_at|name"`
+//	}
+func (c *Context) BindQuery(v interface{}) error {
+	values := withDefaults(c.Request.URL.Query(), v, "query")
+	if err := bindValues(values, v, "query"); err != nil {
+		if ve, ok := err.(*ValidationError); ok {
+			return UnprocessableEntity("Invalid query parameters", ValidationErrors{ve})
+		}
+		return err
+	}
+
+	validator := NewValidator()
+	if !validator.Validate(v) {
+		return UnprocessableEntity("Invalid query parameters", ValidationErrors(convertToValidationErrors(validator.Errors())))
+	}
+	return nil
+}
+
+// withDefaults returns values plus the default tag of every field of the
+// struct pointed to by v whose parameter is absent.
+func withDefaults(values map[string][]string, v interface{}, tag string) map[string][]string {
+	rt := reflect.TypeOf(v)
+	if rt.Kind() != reflect.Ptr || rt.Elem().Kind() != reflect.Struct {
+		return values
+	}
+	rt = rt.Elem()
+
+	out := make(map[string][]string, len(values))
+	for k, vals := range values {
+		out[k] = vals
+	}
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		def, ok := sf.Tag.Lookup("default")
+		if !ok || !sf.IsExported() {
+			continue
+		}
+		if name := fieldKey(sf, tag); len(out[name]) == 0 {
+			out[name] = []string{def}
+		}
+	}
+	return out
+}
+
+// MustBind is ShouldBind that also writes an error response when binding
+// fails. The error is still returned so the handler can stop; the router
+// won't write a second response for it.
+//
+//	if err := c.MustBind(&user); err != nil {
+//		return err
+//	}
+func (c *Context) MustBind(v interface{}) error {
+	return c.mustBind(c.ShouldBind(v))
+}
+
+// MustBindJSON is ShouldBindJSON that writes an error response on failure.
+func (c *Context) MustBindJSON(v interface{}) error {
+	return c.mustBind(c.ShouldBindJSON(v))
+}
+
+// MustBindQuery is ShouldBindQuery that writes an error response on failure.
+func (c *Context) MustBindQuery(v interface{}) error {
+	return c.mustBind(c.ShouldBindQuery(v))
+}
+
+// mustBind responds to a bind error with 400, or with the more specific
+// client error status the error maps to, such as 415.
+func (c *Context) mustBind(err error) error {
+	if err == nil {
+		return nil
+	}
+	status := GetHTTPStatusCode(err)
+	if status >= 500 {
+		status = 400
+	}
+	c.envelope(status, map[string]any{"status": "error", "message": err.Error()})
+	return err
+}
+
+// bindValues copies string values (query parameters, form fields, ...) into
+// the struct pointed to by v, converting them to the field types.
+func bindValues(values map[string][]string, v interface{}, tag string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("routix: bind target must be a pointer to a struct, got %T", v)
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := fieldKey(sf, tag)
+		if name == "-" {
+			continue
+		}
+		vals := values[name]
+		if len(vals) == 0 {
+			continue
+		}
+		if err := setField(rv.Field(i), vals); err != nil {
+			return NewValidationError(name, err.Error())
+		}
+	}
+	return nil
+}
+
+// defaultMultipartMemory is how much of a multipart body Bind keeps in
+// memory; larger file parts are stored in temporary files.
+const defaultMultipartMemory = 32 << 20
+
+var (
+	fileHeaderType  = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeadersType = reflect.TypeOf([]*multipart.FileHeader(nil))
+)
+
+// bindFiles sets the struct fields of v that carry a file tag from the
+// uploaded files of the same name.
+func bindFiles(files map[string][]*multipart.FileHeader, v interface{}) error {
+	rv := reflect.ValueOf(v).Elem()
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("file"), ",")
+		if !sf.IsExported() || name == "" || name == "-" {
+			continue
+		}
+		headers := files[name]
+		if len(headers) == 0 {
+			continue
+		}
+		switch sf.Type {
+		case fileHeaderType:
+			rv.Field(i).Set(reflect.ValueOf(headers[0]))
+		case fileHeadersType:
+			rv.Field(i).Set(reflect.ValueOf(headers))
+		default:
+			return NewValidationError(name, fmt.Sprintf("unsupported file field type %s", sf.Type))
+		}
+	}
+	return nil
+}
+
+// fieldKey returns the parameter name for a struct field: the name in tag,
+// else the json tag name, else the field name.
+func fieldKey(sf reflect.StructField, tag string) string {
+	for _, key := range []string{tag, "json"} {
+		if name, _, _ := strings.Cut(sf.Tag.Get(key), ","); name != "" {
+			return name
+		}
+	}
+	return sf.Name
+}
+
+func setField(field reflect.Value, vals []string) error {
+	if field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(field.Type(), len(vals), len(vals))
+		for i, s := range vals {
+			if err := setScalar(slice.Index(i), s); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+	return setScalar(field, vals[0])
+}
+
+func setScalar(field reflect.Value, s string) error {
+	switch field.Type() {
+	case durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", s)
+		}
+		field.SetInt(int64(d))
+		return nil
+	case timeType:
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return fmt.Errorf("invalid time %q, want RFC 3339", s)
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.Ptr:
+		elem := reflect.New(field.Type().Elem())
+		if err := setScalar(elem.Elem(), s); err != nil {
+			return err
+		}
+		field.Set(elem)
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer %q", s)
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package routix
 
 import (
 	"net/http"
+	"os"
 	"time"
 )
 
@@ -26,6 +27,12 @@ func (api *APIBuilder) Dev() *APIBuilder {
 	return api
 }
 
+// Quiet suppresses the startup banner and dev endpoint printout.
+func (api *APIBuilder) Quiet() *APIBuilder {
+	api.router.Quiet()
+	return api
+}
+
 func (api *APIBuilder) Prod() *APIBuilder {
 	api.router.Use(
 		Recovery(),
@@ -73,6 +80,12 @@ func (api *APIBuilder) JSON() *APIBuilder {
 	return api
 }
 
+// processStart is when the process started, for the uptime Health reports.
+var processStart = time.Now()
+
+// Health registers a health check at path (default /health) reporting the
+// status, uptime in seconds, and the version and build time set with
+// Version or Router.SetVersion.
 func (api *APIBuilder) Health(path string) *APIBuilder {
 	if path == "" {
 		path = "/health"
@@ -80,14 +93,23 @@ func (api *APIBuilder) Health(path string) *APIBuilder {
 
 	api.router.GET(path, func(c *Context) error {
 		return c.Success(map[string]interface{}{
-			"status":    "healthy",
-			"timestamp": time.Now().UTC(),
-			"service":   "routix-api",
+			"status":     "healthy",
+			"timestamp":  time.Now().UTC(),
+			"service":    "routix-api",
+			"uptime":     time.Since(processStart).Seconds(),
+			"version":    c.router.version,
+			"build_time": c.router.buildTime,
 		})
 	})
 	return api
 }
 
+// Version sets the version and build time reported by Health.
+func (api *APIBuilder) Version(version, buildTime string) *APIBuilder {
+	api.router.SetVersion(version, buildTime)
+	return api
+}
+
 func (api *APIBuilder) Metrics(path string) *APIBuilder {
 	if path == "" {
 		path = "/metrics"
@@ -130,28 +152,28 @@ func (api *APIBuilder) CRUD(path string, controller ResourceController) *APIBuil
 	return api
 }
 
-func (api *APIBuilder) GET(path string, handler Handler) *APIBuilder {
-	api.router.GET(path, handler)
+func (api *APIBuilder) GET(path string, handler Handler, middleware ...Middleware) *APIBuilder {
+	api.router.GET(path, handler, middleware...)
 	return api
 }
 
-func (api *APIBuilder) POST(path string, handler Handler) *APIBuilder {
-	api.router.POST(path, handler)
+func (api *APIBuilder) POST(path string, handler Handler, middleware ...Middleware) *APIBuilder {
+	api.router.POST(path, handler, middleware...)
 	return api
 }
 
-func (api *APIBuilder) PUT(path string, handler Handler) *APIBuilder {
-	api.router.PUT(path, handler)
+func (api *APIBuilder) PUT(path string, handler Handler, middleware ...Middleware) *APIBuilder {
+	api.router.PUT(path, handler, middleware...)
 	return api
 }
 
-func (api *APIBuilder) DELETE(path string, handler Handler) *APIBuilder {
-	api.router.DELETE(path, handler)
+func (api *APIBuilder) DELETE(path string, handler Handler, middleware ...Middleware) *APIBuilder {
+	api.router.DELETE(path, handler, middleware...)
 	return api
 }
 
-func (api *APIBuilder) PATCH(path string, handler Handler) *APIBuilder {
-	api.router.PATCH(path, handler)
+func (api *APIBuilder) PATCH(path string, handler Handler, middleware ...Middleware) *APIBuilder {
+	api.router.PATCH(path, handler, middleware...)
 	return api
 }
 
@@ -164,7 +186,20 @@ func (api *APIBuilder) Start(addr string) error {
 		api.router.GET("/", WelcomeHandler("Routix"))
 	}
 
-	printBanner(addr, DevMode)
+	if file := os.Getenv(ExportSpecEnv); file != "" {
+		return exportSpec(api.router, file)
+	}
+	if file := os.Getenv(ExportRoutesEnv); file != "" {
+		return exportRoutes(api.router, file)
+	}
+
+	if err := api.router.checkStart(); err != nil {
+		return err
+	}
+
+	if api.router.showBanner() {
+		printBanner(addr, DevMode)
+	}
 
 	srv := &http.Server{
 		Addr:         addr,
@@ -174,7 +209,7 @@ func (api *APIBuilder) Start(addr string) error {
 		IdleTimeout:  60 * time.Second,
 	}
 
-	return listenAndServe(srv)
+	return listenAndServe(srv, api.router.Reload)
 }
 
 // StartTLS starts the server with TLS support.
@@ -183,7 +218,13 @@ func (api *APIBuilder) StartTLS(addr, certFile, keyFile string) error {
 		api.router.GET("/", WelcomeHandler("Routix"))
 	}
 
-	printBanner(addr, DevMode)
+	if err := api.router.checkStart(); err != nil {
+		return err
+	}
+
+	if api.router.showBanner() {
+		printBanner(addr, DevMode)
+	}
 
 	srv := &http.Server{
 		Addr:         addr,
@@ -0,0 +1,192 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// exportSpecEnv mirrors routix.ExportSpecEnv: when set, the app writes its
+// OpenAPI spec to the named file and exits instead of serving.
+const exportSpecEnv = "ROUTIX_EXPORT_SPEC"
+
+func DocsCommand(args []string) {
+	if len(args) == 0 || args[0] != "export" {
+		showDocsHelp()
+		return
+	}
+
+	format := "openapi"
+	for i, arg := range args[1:] {
+		if strings.HasPrefix(arg, "--format=") {
+			format = strings.TrimPrefix(arg, "--format=")
+		} else if arg == "--format" && i+2 < len(args) {
+			format = args[i+2]
+		}
+	}
+
+	if format != "openapi" && format != "postman" {
+		fmt.Printf("error: unknown format: %s (use openapi or postman)\n", format)
+		return
+	}
+
+	exportDocs(format)
+}
+
+func showDocsHelp() {
+	fmt.Print(`
+Docs Commands:
+  routix docs:export                    Write docs/openapi.json
+  routix docs:export --format=postman   Also write docs/postman_collection.json
+` + "\n")
+}
+
+func exportDocs(format string) {
+	specFile := filepath.Join("docs", "openapi.json")
+	fmt.Printf("Building application to export API docs...\n")
+
+	cmd := exec.Command("go", "run", "main.go")
+	cmd.Env = append(os.Environ(), exportSpecEnv+"="+specFile)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		fmt.Printf("error: exporting spec: %v\n", err)
+		return
+	}
+
+	data, err := os.ReadFile(specFile)
+	if err != nil {
+		fmt.Printf("error: reading spec: %v\n", err)
+		return
+	}
+	fmt.Printf("created: %s\n", specFile)
+
+	if format != "postman" {
+		return
+	}
+
+	var spec map[string]interface{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		fmt.Printf("error: parsing spec: %v\n", err)
+		return
+	}
+
+	out, err := json.MarshalIndent(openAPIToPostman(spec), "", "  ")
+	if err != nil {
+		fmt.Printf("error: encoding collection: %v\n", err)
+		return
+	}
+
+	collectionFile := filepath.Join("docs", "postman_collection.json")
+	if err := os.WriteFile(collectionFile, out, 0644); err != nil {
+		fmt.Printf("error: writing collection: %v\n", err)
+		return
+	}
+	fmt.Printf("created: %s\n", collectionFile)
+}
+
+type postmanCollection struct {
+	Info     postmanInfo       `json:"info"`
+	Item     []postmanItem     `json:"item"`
+	Variable []postmanVariable `json:"variable"`
+}
+
+type postmanInfo struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+type postmanItem struct {
+	Name    string         `json:"name"`
+	Request postmanRequest `json:"request"`
+}
+
+type postmanRequest struct {
+	Method string     `json:"method"`
+	URL    postmanURL `json:"url"`
+}
+
+type postmanURL struct {
+	Raw  string   `json:"raw"`
+	Host []string `json:"host"`
+	Path []string `json:"path"`
+}
+
+type postmanVariable struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// openAPIToPostman converts an OpenAPI document into a Postman v2.1
+// collection with one request per operation, ordered by path and method.
+func openAPIToPostman(spec map[string]interface{}) postmanCollection {
+	name := "Routix API"
+	if info, ok := spec["info"].(map[string]interface{}); ok {
+		if title, ok := info["title"].(string); ok && title != "" {
+			name = title
+		}
+	}
+
+	collection := postmanCollection{
+		Info: postmanInfo{
+			Name:   name,
+			Schema: "https://schema.getpostman.com/json/collection/v2.1.0/collection.json",
+		},
+		Item:     []postmanItem{},
+		Variable: []postmanVariable{{Key: "baseUrl", Value: "http://localhost:8080"}},
+	}
+
+	paths, _ := spec["paths"].(map[string]interface{})
+	pathNames := make([]string, 0, len(paths))
+	for path := range paths {
+		pathNames = append(pathNames, path)
+	}
+	sort.Strings(pathNames)
+
+	for _, path := range pathNames {
+		operations, _ := paths[path].(map[string]interface{})
+		methods := make([]string, 0, len(operations))
+		for method := range operations {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+
+		segments := postmanPath(path)
+		for _, method := range methods {
+			upper := strings.ToUpper(method)
+			collection.Item = append(collection.Item, postmanItem{
+				Name: upper + " " + path,
+				Request: postmanRequest{
+					Method: upper,
+					URL: postmanURL{
+						Raw:  "{{baseUrl}}/" + strings.Join(segments, "/"),
+						Host: []string{"{{baseUrl}}"},
+						Path: segments,
+					},
+				},
+			})
+		}
+	}
+
+	return collection
+}
+
+// postmanPath splits an OpenAPI path into segments, turning {id} into the
+// Postman path variable form :id.
+func postmanPath(path string) []string {
+	segments := []string{}
+	for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			part = ":" + part[1:len(part)-1]
+		}
+		segments = append(segments, part)
+	}
+	return segments
+}
@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOpenAPIToPostman(t *testing.T) {
+	spec := map[string]interface{}{
+		"openapi": "3.0.0",
+		"info":    map[string]interface{}{"title": "Store API", "version": "1.0.0"},
+		"paths": map[string]interface{}{
+			"/users/{id}": map[string]interface{}{
+				"get":    map[string]interface{}{},
+				"delete": map[string]interface{}{},
+			},
+			"/users": map[string]interface{}{
+				"post": map[string]interface{}{},
+			},
+		},
+	}
+
+	collection := openAPIToPostman(spec)
+
+	if collection.Info.Name != "Store API" {
+		t.Fatalf("expected collection name from spec title, got %q", collection.Info.Name)
+	}
+	if len(collection.Item) != 3 {
+		t.Fatalf("expected 3 requests got %d", len(collection.Item))
+	}
+
+	first := collection.Item[0]
+	if first.Name != "POST /users" || first.Request.Method != "POST" {
+		t.Fatalf("unexpected first item: %+v", first)
+	}
+
+	show := collection.Item[2]
+	if show.Request.Method != "GET" {
+		t.Fatalf("expected GET got %s", show.Request.Method)
+	}
+	if show.Request.URL.Raw != "{{baseUrl}}/users/:id" {
+		t.Fatalf("unexpected raw url: %s", show.Request.URL.Raw)
+	}
+	if !reflect.DeepEqual(show.Request.URL.Path, []string{"users", ":id"}) {
+		t.Fatalf("unexpected path segments: %v", show.Request.URL.Path)
+	}
+}
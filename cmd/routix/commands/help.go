@@ -40,6 +40,7 @@ Database Commands:
 Development Tools:
   route list              Show all registered routes
   route cache             Cache routes for better performance
+  docs:export             Export OpenAPI spec to docs/ (--format=openapi|postman)
   test                    Run all tests
   test unit               Run unit tests only
   test integration        Run integration tests only
@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// liveReloadPath is the SSE endpoint the injected script subscribes to.
+const liveReloadPath = "/__routix/livereload"
+
+const liveReloadScript = `<script>(function(){var es=new EventSource("` + liveReloadPath + `");` +
+	`es.onmessage=function(e){if(e.data==="reload"){location.reload();}};})();</script>`
+
+// liveReload fans reload events out to every connected browser.
+type liveReload struct {
+	mu      sync.Mutex
+	clients map[chan struct{}]struct{}
+}
+
+func newLiveReload() *liveReload {
+	return &liveReload{clients: make(map[chan struct{}]struct{})}
+}
+
+func (lr *liveReload) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ch := make(chan struct{}, 1)
+	lr.mu.Lock()
+	lr.clients[ch] = struct{}{}
+	lr.mu.Unlock()
+
+	defer func() {
+		lr.mu.Lock()
+		delete(lr.clients, ch)
+		lr.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ch:
+			fmt.Fprint(w, "data: reload\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// Broadcast tells every connected browser to reload. It never blocks.
+func (lr *liveReload) Broadcast() {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	for ch := range lr.clients {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// newDevProxy forwards requests to the app and injects the live-reload
+// script into HTML responses.
+func newDevProxy(target *url.URL, reload *liveReload) http.Handler {
+	proxy := httputil.NewSingleHostReverseProxy(target)
+
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		// Ask for an uncompressed body so HTML can be rewritten.
+		req.Header.Del("Accept-Encoding")
+	}
+	proxy.ModifyResponse = injectLiveReload
+
+	mux := http.NewServeMux()
+	mux.Handle(liveReloadPath, reload)
+	mux.Handle("/", proxy)
+	return mux
+}
+
+func injectLiveReload(resp *http.Response) error {
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		return nil
+	}
+	if resp.Header.Get("Content-Encoding") != "" {
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	body = injectScript(body)
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return nil
+}
+
+// injectScript places the live-reload script before </body>, or appends it
+// when the document has no closing body tag.
+func injectScript(html []byte) []byte {
+	idx := bytes.LastIndex(bytes.ToLower(html), []byte("</body>"))
+	if idx == -1 {
+		return append(html, liveReloadScript...)
+	}
+
+	out := make([]byte, 0, len(html)+len(liveReloadScript))
+	out = append(out, html[:idx]...)
+	out = append(out, liveReloadScript...)
+	out = append(out, html[idx:]...)
+	return out
+}
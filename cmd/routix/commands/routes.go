@@ -1,7 +1,11 @@
 package commands
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 )
 
@@ -22,36 +26,60 @@ func RouteCommand(args []string) {
 	}
 }
 
+// exportRoutesEnv mirrors routix.ExportRoutesEnv: when set, the app writes
+// its route table to the named file and exits instead of serving.
+const exportRoutesEnv = "ROUTIX_EXPORT_ROUTES"
+
 func listRoutes() {
-	fmt.Printf("Registered Routes:\n\n")
-	
-	routes := []Route{
-		{Method: "GET", URI: "/", Name: "welcome", Action: "WelcomeController@index", Middleware: []string{}},
-		{Method: "GET", URI: "/health", Name: "health", Action: "HealthController@check", Middleware: []string{}},
-		{Method: "GET", URI: "/api/v1/users", Name: "users.index", Action: "UserController@index", Middleware: []string{"auth", "throttle"}},
-		{Method: "POST", URI: "/api/v1/users", Name: "users.store", Action: "UserController@store", Middleware: []string{"auth", "throttle"}},
-		{Method: "GET", URI: "/api/v1/users/{id}", Name: "users.show", Action: "UserController@show", Middleware: []string{"auth"}},
-		{Method: "PUT", URI: "/api/v1/users/{id}", Name: "users.update", Action: "UserController@update", Middleware: []string{"auth"}},
-		{Method: "DELETE", URI: "/api/v1/users/{id}", Name: "users.destroy", Action: "UserController@destroy", Middleware: []string{"auth"}},
+	dir, err := os.MkdirTemp("", "routix-routes")
+	if err != nil {
+		fmt.Printf("error: %v\n", err)
+		return
 	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "routes.json")
 
-	fmt.Printf("| %-8s | %-25s | %-20s | %-25s | %-15s |\n", 
-		"Method", "URI", "Name", "Action", "Middleware")
-	fmt.Printf("|----------|---------------------------|----------------------|---------------------------|------------------|\n")
+	cmd := exec.Command("go", "run", "main.go")
+	cmd.Env = append(os.Environ(), exportRoutesEnv+"="+file)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		fmt.Printf("error: loading routes: %v\n", err)
+		return
+	}
 
-	for _, route := range routes {
-		middlewareStr := strings.Join(route.Middleware, ",")
-		if middlewareStr == "" {
-			middlewareStr = "-"
-		}
-		
-		fmt.Printf("| %-8s | %-25s | %-20s | %-25s | %-15s |\n",
-			route.Method, route.URI, route.Name, route.Action, middlewareStr)
+	data, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Printf("error: reading routes: %v\n", err)
+		return
+	}
+	var routes []Route
+	if err := json.Unmarshal(data, &routes); err != nil {
+		fmt.Printf("error: parsing routes: %v\n", err)
+		return
 	}
 
+	fmt.Printf("Registered Routes:\n\n")
+	fmt.Print(formatRoutes(routes))
 	fmt.Printf("\nTotal routes: %d\n", len(routes))
 }
 
+// formatRoutes renders routes as a table, one row per route, with the
+// names of the middleware that run for it, outermost first.
+func formatRoutes(routes []Route) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "| %-8s | %-30s | %s\n", "Method", "URI", "Middleware")
+	fmt.Fprintf(&b, "|----------|--------------------------------|------------\n")
+	for _, route := range routes {
+		middleware := strings.Join(route.Middleware, ", ")
+		if middleware == "" {
+			middleware = "-"
+		}
+		fmt.Fprintf(&b, "| %-8s | %-30s | %s\n", route.Method, route.URI, middleware)
+	}
+	return b.String()
+}
+
 func cacheRoutes() {
 	fmt.Printf("Caching routes...\n")
 	fmt.Printf("Routes cached\n")
@@ -63,9 +91,7 @@ func clearRouteCache() {
 }
 
 type Route struct {
-	Method     string
-	URI        string
-	Name       string
-	Action     string
-	Middleware []string
-}
\ No newline at end of file
+	Method     string   `json:"method"`
+	URI        string   `json:"path"`
+	Middleware []string `json:"middleware"`
+}
@@ -0,0 +1,29 @@
+package commands
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestFormatRoutes(t *testing.T) {
+	data := `[
+		{"method": "GET", "path": "/api/me", "middleware": ["routix.Logger", "auth"]},
+		{"method": "GET", "path": "/health", "middleware": null}
+	]`
+	var routes []Route
+	if err := json.Unmarshal([]byte(data), &routes); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(formatRoutes(routes)), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected header, rule and 2 rows, got:\n%s", strings.Join(lines, "\n"))
+	}
+	if !strings.Contains(lines[2], "/api/me") || !strings.HasSuffix(lines[2], "| routix.Logger, auth") {
+		t.Errorf("unexpected row: %q", lines[2])
+	}
+	if !strings.HasSuffix(lines[3], "| -") {
+		t.Errorf("route without middleware: %q", lines[3])
+	}
+}
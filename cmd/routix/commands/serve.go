@@ -2,10 +2,14 @@ package commands
 
 import (
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -29,18 +33,34 @@ func ServeCommand(args []string) {
 		}
 	}
 
+	// The app itself listens on the next port; the dev server sits in front
+	// of it so it can inject the live-reload script into HTML responses.
+	appPort := "8081"
+	if p, err := strconv.Atoi(port); err == nil {
+		appPort = strconv.Itoa(p + 1)
+	}
+
 	fmt.Printf("Starting Routix development server...\n")
 	fmt.Printf("http://%s:%s\n", host, port)
 	fmt.Printf("Watching for file changes...\n\n")
 
 	os.Setenv("APP_ENV", "development")
-	os.Setenv("APP_PORT", port)
+	os.Setenv("APP_PORT", appPort)
 	os.Setenv("APP_HOST", host)
 
-	startFileWatcher()
+	reload := newLiveReload()
+	target := &url.URL{Scheme: "http", Host: net.JoinHostPort(host, appPort)}
+	go func() {
+		addr := net.JoinHostPort(host, port)
+		if err := http.ListenAndServe(addr, newDevProxy(target, reload)); err != nil {
+			fmt.Printf("error: dev server: %v\n", err)
+		}
+	}()
+
+	startFileWatcher(target.Host, reload)
 }
 
-func startFileWatcher() {
+func startFileWatcher(appAddr string, reload *liveReload) {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		fmt.Printf("error: creating file watcher: %v\n", err)
@@ -53,6 +73,8 @@ func startFileWatcher() {
 		"app",
 		"config",
 		"routes",
+		"resources",
+		"public",
 	}
 
 	for _, dir := range watchDirs {
@@ -74,6 +96,13 @@ func startFileWatcher() {
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 		cmd.Start()
+
+		// Reload connected browsers once the new process accepts connections.
+		go func() {
+			if waitForServer(appAddr, 30*time.Second) {
+				reload.Broadcast()
+			}
+		}()
 	}
 
 	restartServer()
@@ -92,6 +121,7 @@ func startFileWatcher() {
 
 	debounce := time.NewTimer(0)
 	debounce.Stop()
+	pending := changeNone
 
 	for {
 		select {
@@ -100,12 +130,21 @@ func startFileWatcher() {
 				return
 			}
 
-			if shouldRestart(event) {
+			if kind := watchedChange(event); kind != changeNone {
+				if kind > pending {
+					pending = kind
+				}
 				debounce.Reset(500 * time.Millisecond)
 			}
 
 		case <-debounce.C:
-			restartServer()
+			switch pending {
+			case changeRestart:
+				restartServer()
+			case changeReload:
+				reload.Broadcast()
+			}
+			pending = changeNone
 
 		case err, ok := <-watcher.Errors:
 			if !ok {
@@ -150,20 +189,67 @@ func shouldIgnoreDir(path string) bool {
 	return false
 }
 
-func shouldRestart(event fsnotify.Event) bool {
+// changeKind describes how the dev server reacts to a changed file.
+type changeKind int
+
+const (
+	changeNone changeKind = iota
+	changeReload
+	changeRestart
+)
+
+func watchedChange(event fsnotify.Event) changeKind {
 	if event.Op&fsnotify.Write == 0 {
-		return false
+		return changeNone
 	}
 
-	ext := filepath.Ext(event.Name)
-	restartExts := []string{".go", ".env", ".yaml", ".yml", ".json"}
+	kind := classifyChange(event.Name)
+	if kind != changeNone {
+		fmt.Printf("changed: %s\n", event.Name)
+	}
+	return kind
+}
 
+// classifyChange reports whether a change to name requires rebuilding the
+// server or only reloading the browser. Templates and assets only count when
+// they live under resources/ or public/.
+func classifyChange(name string) changeKind {
+	ext := filepath.Ext(name)
+	restartExts := []string{".go", ".env", ".yaml", ".yml", ".json"}
 	for _, restartExt := range restartExts {
 		if ext == restartExt {
-			fmt.Printf("changed: %s\n", event.Name)
-			return true
+			return changeRestart
+		}
+	}
+
+	if !isAssetPath(name) {
+		return changeNone
+	}
+
+	reloadExts := []string{".html", ".htm", ".tmpl", ".gohtml", ".css", ".js"}
+	for _, reloadExt := range reloadExts {
+		if ext == reloadExt {
+			return changeReload
 		}
 	}
 
+	return changeNone
+}
+
+func isAssetPath(name string) bool {
+	name = "/" + strings.TrimPrefix(filepath.ToSlash(name), "./")
+	return strings.Contains(name, "/resources/") || strings.Contains(name, "/public/")
+}
+
+func waitForServer(addr string, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		conn, err := net.DialTimeout("tcp", addr, 200*time.Millisecond)
+		if err == nil {
+			conn.Close()
+			return true
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
 	return false
 }
@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClassifyChange(t *testing.T) {
+	cases := []struct {
+		name string
+		want changeKind
+	}{
+		{"main.go", changeRestart},
+		{"app/controllers/user.go", changeRestart},
+		{".env", changeRestart},
+		{"config/app.yaml", changeRestart},
+		{"resources/views/home.html", changeReload},
+		{"resources/views/layout.tmpl", changeReload},
+		{"./resources/views/index.gohtml", changeReload},
+		{"public/css/app.css", changeReload},
+		{"public/js/app.js", changeReload},
+		{"app/assets/app.js", changeNone},
+		{"docs/notes.html", changeNone},
+		{"public/logo.png", changeNone},
+	}
+
+	for _, tc := range cases {
+		if got := classifyChange(tc.name); got != tc.want {
+			t.Errorf("classifyChange(%q) = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestInjectScript(t *testing.T) {
+	out := string(injectScript([]byte("<html><body><h1>hi</h1></BODY></html>")))
+	if !strings.Contains(out, liveReloadScript+"</BODY>") {
+		t.Fatalf("script not injected before </body>: %s", out)
+	}
+
+	out = string(injectScript([]byte("<p>fragment</p>")))
+	if !strings.HasSuffix(out, liveReloadScript) {
+		t.Fatalf("script not appended to fragment: %s", out)
+	}
+}
@@ -39,6 +39,12 @@ func main() {
 		commands.SeedCommand(args)
 	case command == "route":
 		commands.RouteCommand(args)
+	case command == "docs" || strings.HasPrefix(command, "docs:"):
+		if strings.HasPrefix(command, "docs:") {
+			commands.DocsCommand(append([]string{strings.TrimPrefix(command, "docs:")}, args...))
+		} else {
+			commands.DocsCommand(args)
+		}
 	case command == "test":
 		commands.TestCommand(args)
 	case command == "install":
@@ -1,11 +1,17 @@
 package routix
 
 import (
+	"encoding/json"
 	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"net/http"
+	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -21,6 +27,7 @@ type RouteDefinition struct {
 	Middleware  []string
 	RequestBody Schema
 	Response    Schema
+	Responses   map[int]Schema
 }
 
 func NewCodeGenerator() *CodeGenerator {
@@ -146,24 +153,213 @@ func (cg *CodeGenerator) GenerateOpenAPISpec() map[string]interface{} {
 	paths := spec["paths"].(map[string]interface{})
 	
 	for _, route := range cg.routes {
-		if paths[route.Path] == nil {
-			paths[route.Path] = make(map[string]interface{})
+		path, params := openAPIPath(route.Path)
+		if paths[path] == nil {
+			paths[path] = make(map[string]interface{})
 		}
 		
-		pathItem := paths[route.Path].(map[string]interface{})
-		pathItem[strings.ToLower(route.Method)] = map[string]interface{}{
-			"summary": fmt.Sprintf("%s %s", route.Method, route.Path),
-			"responses": map[string]interface{}{
-				"200": map[string]interface{}{
-					"description": "Success",
+		pathItem := paths[path].(map[string]interface{})
+		responses := map[string]interface{}{}
+		if route.Response != nil {
+			responses["200"] = openAPIResponse(http.StatusOK, route.Response)
+		}
+		for status, schema := range route.Responses {
+			responses[strconv.Itoa(status)] = openAPIResponse(status, schema)
+		}
+		if len(responses) == 0 {
+			responses["200"] = map[string]interface{}{
+				"description": "Success",
+			}
+		}
+		operation := map[string]interface{}{
+			"summary":   fmt.Sprintf("%s %s", route.Method, route.Path),
+			"responses": responses,
+		}
+		if route.RequestBody != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": openAPISchema(route.RequestBody),
+					},
 				},
-			},
+			}
 		}
+		if len(params) > 0 {
+			parameters := make([]interface{}, 0, len(params))
+			for _, name := range params {
+				parameters = append(parameters, map[string]interface{}{
+					"name":     name,
+					"in":       "path",
+					"required": true,
+					"schema":   map[string]interface{}{"type": "string"},
+				})
+			}
+			operation["parameters"] = parameters
+		}
+		pathItem[strings.ToLower(route.Method)] = operation
 	}
 	
 	return spec
 }
 
+func openAPIResponse(status int, schema Schema) map[string]interface{} {
+	description := http.StatusText(status)
+	if description == "" {
+		description = "Response"
+	}
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{
+				"schema": openAPISchema(schema),
+			},
+		},
+	}
+}
+
+// openAPISchema describes a Schema as an OpenAPI schema object.
+func openAPISchema(schema Schema) map[string]interface{} {
+	switch s := schema.(type) {
+	case *StringSchema:
+		out := map[string]interface{}{"type": "string"}
+		if s.min > 0 {
+			out["minLength"] = s.min
+		}
+		if s.max > 0 {
+			out["maxLength"] = s.max
+		}
+		return out
+	case *NumberSchema:
+		out := map[string]interface{}{"type": "number"}
+		if s.integer {
+			out["type"] = "integer"
+		}
+		if s.min != nil {
+			out["minimum"] = *s.min
+		}
+		if s.max != nil {
+			out["maximum"] = *s.max
+		}
+		return out
+	case *BooleanSchema:
+		return map[string]interface{}{"type": "boolean"}
+	case *ArraySchema:
+		out := map[string]interface{}{"type": "array"}
+		if s.itemSchema != nil {
+			out["items"] = openAPISchema(s.itemSchema)
+		}
+		if s.minItems != nil {
+			out["minItems"] = *s.minItems
+		}
+		if s.maxItems != nil {
+			out["maxItems"] = *s.maxItems
+		}
+		if s.unique {
+			out["uniqueItems"] = true
+		}
+		return out
+	case *ObjectSchema:
+		properties := map[string]interface{}{}
+		var required []string
+		for name, field := range s.fields {
+			properties[name] = openAPISchema(field)
+			if field.IsRequired() {
+				required = append(required, name)
+			}
+		}
+		out := map[string]interface{}{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			sort.Strings(required)
+			out["required"] = required
+		}
+		if s.strict {
+			out["additionalProperties"] = false
+		}
+		if s.allowNull {
+			out["nullable"] = true
+		}
+		return out
+	case *EnumSchema:
+		return map[string]interface{}{"enum": s.values}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// openAPIPath converts a routix pattern such as /users/:id into the OpenAPI
+// form /users/{id} and returns the path parameter names in order.
+func openAPIPath(path string) (string, []string) {
+	var params []string
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		switch {
+		case strings.HasPrefix(part, ":"):
+			params = append(params, part[1:])
+			parts[i] = "{" + part[1:] + "}"
+		case part == "*":
+			params = append(params, "path")
+			parts[i] = "{path}"
+		}
+	}
+	return strings.Join(parts, "/"), params
+}
+
+// OpenAPISpec builds an OpenAPI 3 document from the registered routes.
+func (r *Router) OpenAPISpec() map[string]interface{} {
+	cg := NewCodeGenerator()
+	for _, route := range r.Routes() {
+		cg.AddRoute(RouteDefinition{
+			Method:      route.Method,
+			Path:        route.Path,
+			RequestBody: route.Request,
+			Responses:   route.Responses,
+		})
+	}
+	return cg.GenerateOpenAPISpec()
+}
+
+// ExportSpecEnv names the environment variable that makes Start write the
+// OpenAPI spec to the given file and return instead of serving. The
+// docs:export CLI command relies on it.
+const ExportSpecEnv = "ROUTIX_EXPORT_SPEC"
+
+func exportSpec(r *Router, file string) error {
+	data, err := json.MarshalIndent(r.OpenAPISpec(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0644)
+}
+
+// ExportRoutesEnv names the environment variable that makes Start write
+// the route table, with middleware names, as JSON to the given file and
+// return instead of serving. The route:list CLI command relies on it.
+const ExportRoutesEnv = "ROUTIX_EXPORT_ROUTES"
+
+func exportRoutes(r *Router, file string) error {
+	type route struct {
+		Method     string   `json:"method"`
+		Path       string   `json:"path"`
+		Middleware []string `json:"middleware"`
+	}
+	var routes []route
+	for _, info := range r.Routes() {
+		routes = append(routes, route{info.Method, info.Path, info.Middleware})
+	}
+	data, err := json.MarshalIndent(routes, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0644)
+}
+
 func (cg *CodeGenerator) GenerateTypeScriptTypes() string {
 	var code strings.Builder
 	
@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -210,7 +211,6 @@ func (c EmailConstraint) Validate(value interface{}) error {
 		return fmt.Errorf("email constraint only applicable to strings")
 	}
 	
-	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
 	if !emailRegex.MatchString(str) {
 		return fmt.Errorf("value must be a valid email address")
 	}
@@ -312,7 +312,6 @@ func (c URLConstraint) Validate(value interface{}) error {
 		return fmt.Errorf("url constraint only applicable to strings")
 	}
 	
-	urlRegex := regexp.MustCompile(`^https?://[^\s/$.?#].[^\s]*$`)
 	if !urlRegex.MatchString(str) {
 		return fmt.Errorf("value must be a valid URL")
 	}
@@ -336,7 +335,6 @@ func (c AlphaConstraint) Validate(value interface{}) error {
 		return fmt.Errorf("alpha constraint only applicable to strings")
 	}
 	
-	alphaRegex := regexp.MustCompile(`^[a-zA-Z]+$`)
 	if !alphaRegex.MatchString(str) {
 		return fmt.Errorf("value must contain only alphabetic characters")
 	}
@@ -360,7 +358,6 @@ func (c AlphaNumConstraint) Validate(value interface{}) error {
 		return fmt.Errorf("alphanum constraint only applicable to strings")
 	}
 	
-	alphaNumRegex := regexp.MustCompile(`^[a-zA-Z0-9]+$`)
 	if !alphaNumRegex.MatchString(str) {
 		return fmt.Errorf("value must contain only alphanumeric characters")
 	}
@@ -392,7 +389,37 @@ func (c NumericConstraint) Validate(value interface{}) error {
 	return nil
 }
 
+// Patterns used by the built-in constraints, compiled once. emailRegex is
+// shared with Validator.
+var (
+	urlRegex      = regexp.MustCompile(`^https?://[^\s/$.?#].[^\s]*$`)
+	alphaRegex    = regexp.MustCompile(`^[a-zA-Z]+$`)
+	alphaNumRegex = regexp.MustCompile(`^[a-zA-Z0-9]+$`)
+)
+
+// constraintCache maps a tag to the []Constraint ParseConstraints built
+// for it.
+var constraintCache sync.Map
+
+// ParseConstraints parses a validate tag such as "required,min=3" into
+// constraints. Results are cached by tag, so repeated calls with the same
+// tag return the same constraints, compiled regexes included, without
+// reparsing. The returned slice is shared and must not be modified.
 func ParseConstraints(tag string) ([]Constraint, error) {
+	if cached, ok := constraintCache.Load(tag); ok {
+		return cached.([]Constraint), nil
+	}
+	constraints, err := parseConstraints(tag)
+	if err != nil {
+		return nil, err
+	}
+	// Clip so appends by callers never write into the cached array.
+	constraints = constraints[:len(constraints):len(constraints)]
+	cached, _ := constraintCache.LoadOrStore(tag, constraints)
+	return cached.([]Constraint), nil
+}
+
+func parseConstraints(tag string) ([]Constraint, error) {
 	var constraints []Constraint
 	
 	if tag == "" {
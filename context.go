@@ -1,38 +1,469 @@
 package routix
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 )
 
+var (
+	// ErrEmptyBody is returned when a request body is required but empty.
+	ErrEmptyBody = errors.New("request body is empty")
+	// ErrUnsupportedContentType is returned when a body can't be decoded
+	// because of its Content-Type.
+	ErrUnsupportedContentType = errors.New("unsupported content-type")
+)
+
+// ParseJSON decodes a JSON request body into v. It returns an error
+// wrapping ErrUnsupportedContentType for non-JSON requests and ErrEmptyBody
+// when there is nothing to decode.
 func (c *Context) ParseJSON(v interface{}) error {
-	ct := c.Request.Header.Get("Content-Type")
-	if !strings.HasPrefix(ct, "application/json") {
-		return fmt.Errorf("content-type must be application/json")
+	if !c.IsJSON() {
+		return fmt.Errorf("%w: must be application/json", ErrUnsupportedContentType)
+	}
+	return c.decodeJSON(v)
+}
+
+// decodeJSON decodes the request body into v, enforcing the router's
+// maximum nesting depth. The body is buffered so it can be decoded again,
+// e.g. by Bind followed by ParseJSON.
+func (c *Context) decodeJSON(v interface{}) error {
+	if c.Request.Body == nil && c.bodyBuf == nil {
+		return ErrEmptyBody
+	}
+	data, err := c.BufferBody()
+	if err != nil {
+		return err
+	}
+	return decodeJSON(&depthLimitReader{r: bytes.NewReader(data), max: c.maxJSONDepth()}, v)
+}
+
+func decodeJSON(r io.Reader, v interface{}) error {
+	if r == nil {
+		return ErrEmptyBody
+	}
+	if err := json.NewDecoder(r).Decode(v); err != nil {
+		if err == io.EOF {
+			return ErrEmptyBody
+		}
+		return err
+	}
+	return nil
+}
+
+// DefaultMaxJSONDepth is the nesting depth allowed in JSON request bodies
+// unless changed with Router.MaxJSONDepth.
+const DefaultMaxJSONDepth = 100
+
+// ErrJSONTooDeep is returned when a JSON body nests objects and arrays
+// deeper than the configured limit.
+var ErrJSONTooDeep = errors.New("json body exceeds maximum nesting depth")
+
+func (c *Context) maxJSONDepth() int {
+	if c.router != nil && c.router.jsonDepth > 0 {
+		return c.router.jsonDepth
+	}
+	return DefaultMaxJSONDepth
+}
+
+// depthLimitReader tracks object and array nesting in the JSON passing
+// through it and fails with ErrJSONTooDeep before the decoder recurses past
+// max. Brackets inside strings are ignored.
+type depthLimitReader struct {
+	r        io.Reader
+	max      int
+	depth    int
+	inString bool
+	escaped  bool
+}
+
+func (d *depthLimitReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	if scanErr := d.scan(p[:n]); scanErr != nil {
+		return 0, scanErr
+	}
+	return n, err
+}
+
+func (d *depthLimitReader) scan(p []byte) error {
+	for _, b := range p {
+		if d.inString {
+			switch {
+			case d.escaped:
+				d.escaped = false
+			case b == '\\':
+				d.escaped = true
+			case b == '"':
+				d.inString = false
+			}
+			continue
+		}
+		switch b {
+		case '"':
+			d.inString = true
+		case '{', '[':
+			d.depth++
+			if d.depth > d.max {
+				return ErrJSONTooDeep
+			}
+		case '}', ']':
+			d.depth--
+		}
+	}
+	return nil
+}
+
+// MaxBufferedBodySize caps how many bytes BufferBody will hold in memory.
+var MaxBufferedBodySize int64 = 10 << 20
+
+// ErrBodyTooLarge is returned by BufferBody when the request body exceeds
+// MaxBufferedBodySize.
+var ErrBodyTooLarge = errors.New("request body too large")
+
+// BufferBody reads the request body into memory and replaces it with a
+// re-readable copy, so middleware can inspect the body without starving the
+// handler. Subsequent calls return the same bytes and rewind the body.
+func (c *Context) BufferBody() ([]byte, error) {
+	if c.bodyBuf == nil {
+		if c.Request.Body == nil {
+			return nil, nil
+		}
+		data, err := io.ReadAll(io.LimitReader(c.Request.Body, MaxBufferedBodySize+1))
+		c.Request.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(data)) > MaxBufferedBodySize {
+			return nil, ErrBodyTooLarge
+		}
+		c.bodyBuf = data
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(c.bodyBuf))
+	return c.bodyBuf, nil
+}
+
+// BodyMap returns the JSON request body decoded into a map, decoding it on
+// first use and keeping the result in c.Body. The body stays buffered, so
+// Bind and ParseJSON still work afterwards. Urlencoded form bodies are
+// mapped to the first value of each field. Other, empty, invalid or too
+// deeply nested bodies yield nil.
+func (c *Context) BodyMap() map[string]any {
+	if c.Body != nil || c.Request.Body == nil {
+		return c.Body
+	}
+	if c.IsForm() {
+		form, err := c.ParseForm()
+		if err != nil || len(form) == 0 {
+			return nil
+		}
+		c.Body = make(map[string]any, len(form))
+		for k, v := range form {
+			c.Body[k] = v[0]
+		}
+		return c.Body
+	}
+	if !c.IsJSON() {
+		return nil
+	}
+	data, err := c.BufferBody()
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	if (&depthLimitReader{max: c.maxJSONDepth()}).scan(data) == nil {
+		json.Unmarshal(data, &c.Body) //nolint:errcheck
+	}
+	return c.Body
+}
+
+// ParseForm returns the fields of an application/x-www-form-urlencoded body,
+// parsing it on first use and reusing the result afterwards. The body stays
+// buffered, so RawBody still works. Other content types yield empty values.
+func (c *Context) ParseForm() (url.Values, error) {
+	if c.form != nil {
+		return c.form, nil
+	}
+	if !c.IsForm() {
+		return url.Values{}, nil
+	}
+	data, err := c.BufferBody()
+	if err != nil {
+		return nil, err
+	}
+	form, err := url.ParseQuery(string(data))
+	if err != nil {
+		return nil, err
+	}
+	c.form = form
+	return form, nil
+}
+
+// RawBody returns the request body exactly as the client sent it, for
+// checks such as webhook signature verification. It is BufferBody by
+// another name: the bytes are kept, so Bind and ParseJSON still work
+// afterwards, and bodies over MaxBufferedBodySize fail with
+// ErrBodyTooLarge, which the router answers with 413.
+func (c *Context) RawBody() ([]byte, error) {
+	return c.BufferBody()
+}
+
+// bodyReader returns a reader positioned at the start of the body, using the
+// buffered copy when BufferBody has run.
+func (c *Context) bodyReader() io.Reader {
+	if c.bodyBuf != nil {
+		return bytes.NewReader(c.bodyBuf)
+	}
+	return c.Request.Body
+}
+
+// Copy returns a detached copy of the context that is safe to use from
+// goroutines outliving the handler. The pooled *Context and its maps are
+// recycled as soon as the handler returns, so only the copy may be handed to
+// a goroutine. The copy keeps the request under a fresh background context
+// and discards anything written to its response.
+func (c *Context) Copy() *Context {
+	rw := &responseWriter{ResponseWriter: discardResponseWriter{header: make(http.Header)}}
+	cp := &Context{
+		Request:  c.Request.WithContext(context.Background()),
+		Writer:   rw,
+		Response: rw,
+		Params:   cloneStringMap(c.Params),
+		Query:    cloneStringMap(c.Query),
+		bodyBuf:  c.bodyBuf,
+		form:     c.form,
+		router:   c.router,
+	}
+	if c.Body != nil {
+		cp.Body = make(map[string]any, len(c.Body))
+		for k, v := range c.Body {
+			cp.Body[k] = v
+		}
+	}
+	if c.values != nil {
+		cp.values = make(map[string]any, len(c.values))
+		for k, v := range c.values {
+			cp.values[k] = v
+		}
+	}
+	return cp
+}
+
+func cloneStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// discardResponseWriter swallows writes made through a copied context.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (d discardResponseWriter) Header() http.Header         { return d.header }
+func (d discardResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (d discardResponseWriter) WriteHeader(int)             {}
+
+// ProtoUnmarshaler is implemented by protobuf messages that can decode
+// themselves. See ProtoMarshaler.
+type ProtoUnmarshaler interface {
+	Unmarshal([]byte) error
+}
+
+// ParseProtobuf decodes an application/x-protobuf request body into msg.
+func (c *Context) ParseProtobuf(msg ProtoUnmarshaler) error {
+	mt := mediaType(c.Request.Header.Get("Content-Type"))
+	if mt != "application/x-protobuf" && mt != "application/protobuf" {
+		return fmt.Errorf("content-type must be application/x-protobuf")
 	}
-	return json.NewDecoder(c.Request.Body).Decode(v)
+	data, err := io.ReadAll(c.bodyReader())
+	if err != nil {
+		return err
+	}
+	return msg.Unmarshal(data)
 }
 
-// Cache sets the Cache-Control header so browsers and proxies cache this response.
+// Cache sets the Cache-Control header so browsers and proxies cache this
+// response. It does not cache anything on the server; use Route.Cache for that.
 func (c *Context) Cache(duration time.Duration) {
 	c.Response.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(duration.Seconds())))
 }
 
+// Deprecate marks the response as coming from a deprecated endpoint by
+// setting Deprecation, Sunset (RFC 8594) and a Link with rel="deprecation".
+// A zero sunset or empty link omits the corresponding header.
+func (c *Context) Deprecate(sunset time.Time, link string) {
+	h := c.Response.Header()
+	h.Set("Deprecation", "true")
+	if !sunset.IsZero() {
+		h.Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+	}
+	if link != "" {
+		h.Add("Link", fmt.Sprintf(`<%s>; rel="deprecation"`, link))
+	}
+}
+
+// IfMatch reports whether the request's If-Match precondition holds for the
+// current entity tag. It returns true when the header is absent or "*".
+// Comparison is strong, so weak client tags never match.
+func (c *Context) IfMatch(etag string) bool {
+	header := c.Request.Header.Get("If-Match")
+	if header == "" {
+		return true
+	}
+	if !strings.HasPrefix(etag, `"`) {
+		etag = `"` + etag + `"`
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// IfUnmodifiedSince reports whether the resource, last modified at
+// modified, satisfies the request's If-Unmodified-Since precondition. It
+// returns true when the header is absent or unparseable.
+func (c *Context) IfUnmodifiedSince(modified time.Time) bool {
+	since, err := http.ParseTime(c.Request.Header.Get("If-Unmodified-Since"))
+	if err != nil {
+		return true
+	}
+	return !modified.Truncate(time.Second).After(since)
+}
+
 func (c *Context) Param(name string) string {
 	return c.Params[name]
 }
 
+// ParamInt parses a path parameter as an int. Failures are *ParamError;
+// a missing or empty parameter wraps ErrMissingParam.
 func (c *Context) ParamInt(name string) (int, error) {
-	return strconv.Atoi(c.Params[name])
+	return parseParam(c, name, strconv.Atoi)
 }
 
+// ParamInt64 parses a path parameter as an int64. Failures are
+// *ParamError; a missing or empty parameter wraps ErrMissingParam.
 func (c *Context) ParamInt64(name string) (int64, error) {
-	return strconv.ParseInt(c.Params[name], 10, 64)
+	return parseParam(c, name, func(s string) (int64, error) {
+		return strconv.ParseInt(s, 10, 64)
+	})
+}
+
+// ParamFloat parses a path parameter as a float64. Failures are
+// *ParamError; a missing or empty parameter wraps ErrMissingParam.
+func (c *Context) ParamFloat(name string) (float64, error) {
+	return parseParam(c, name, func(s string) (float64, error) {
+		return strconv.ParseFloat(s, 64)
+	})
+}
+
+// ParamBool parses a path parameter as strconv.ParseBool does, accepting
+// 1, t, true, 0, f, false and their capitalised forms. Failures are
+// *ParamError; a missing or empty parameter wraps ErrMissingParam.
+func (c *Context) ParamBool(name string) (bool, error) {
+	return parseParam(c, name, strconv.ParseBool)
+}
+
+// ParamIntBase parses a path parameter as a signed integer in the given
+// base and bit size, as strconv.ParseInt does. Failures are *ParamError.
+func (c *Context) ParamIntBase(name string, base, bitSize int) (int64, error) {
+	return parseParam(c, name, func(s string) (int64, error) {
+		return strconv.ParseInt(s, base, bitSize)
+	})
+}
+
+// ParamUint parses a path parameter as a base-10 uint64. Failures are
+// *ParamError.
+func (c *Context) ParamUint(name string) (uint64, error) {
+	return parseParam(c, name, func(s string) (uint64, error) {
+		return strconv.ParseUint(s, 10, 64)
+	})
 }
 
+// ParamHex parses a hexadecimal path parameter, such as the id in
+// /objects/:id, as a uint64. A 0x prefix is not accepted. Failures are
+// *ParamError.
+func (c *Context) ParamHex(name string) (uint64, error) {
+	return parseParam(c, name, func(s string) (uint64, error) {
+		return strconv.ParseUint(s, 16, 64)
+	})
+}
+
+// ParamIntRange parses a path parameter as an int between min and max
+// inclusive. Failures are *ParamError; for out-of-range values Err wraps
+// strconv.ErrRange and names the bounds.
+func (c *Context) ParamIntRange(name string, min, max int) (int, error) {
+	n, err := c.ParamInt(name)
+	if err != nil {
+		return 0, err
+	}
+	if n < min || n > max {
+		err := fmt.Errorf("%w: must be between %d and %d", strconv.ErrRange, min, max)
+		return 0, &ParamError{Name: name, Value: c.Params[name], Err: err}
+	}
+	return n, nil
+}
+
+// parseParam looks up a path parameter and parses it with parse, wrapping
+// failures in *ParamError.
+func parseParam[T any](c *Context, name string, parse func(string) (T, error)) (T, error) {
+	var zero T
+	value := c.Params[name]
+	if value == "" {
+		return zero, &ParamError{Name: name, Err: ErrMissingParam}
+	}
+	v, err := parse(value)
+	if err != nil {
+		return zero, &ParamError{Name: name, Value: value, Err: err}
+	}
+	return v, nil
+}
+
+// ErrMissingParam is wrapped by the ParamError returned for a path
+// parameter that is absent or empty, so handlers can answer 404 rather
+// than 400. Returned unchanged it answers 404; to shape the reply:
+//
+//	id, err := c.ParamInt("id")
+//	if errors.Is(err, routix.ErrMissingParam) {
+//		return c.NotFound("no such item")
+//	}
+var ErrMissingParam = errors.New("missing path parameter")
+
+// ParamError reports a path parameter that was missing or couldn't be
+// parsed. Err is ErrMissingParam or the underlying *strconv.NumError, so
+// errors.Is(err, strconv.ErrRange) detects overflow. Returned from a
+// handler it becomes a 404 when missing and a 400 otherwise.
+type ParamError struct {
+	Name  string
+	Value string
+	Err   error
+}
+
+func (e *ParamError) Error() string {
+	if e.Err == ErrMissingParam {
+		return fmt.Sprintf("missing path parameter %s", e.Name)
+	}
+	return fmt.Sprintf("invalid path parameter %s=%q: %v", e.Name, e.Value, e.Err)
+}
+
+func (e *ParamError) Unwrap() error { return e.Err }
+
 func (c *Context) QueryParam(name string) string {
 	return c.Query[name]
 }
@@ -59,24 +490,233 @@ func (c *Context) QueryParamIntDefault(name string, defaultValue int) int {
 	return defaultValue
 }
 
+// QueryIntRange parses a query parameter as an int between min and max
+// inclusive, as for pagination limits. Failures are *ValidationError naming
+// the parameter, which become a 400; as with ParamIntRange, out-of-range
+// values wrap strconv.ErrRange.
+func (c *Context) QueryIntRange(name string, min, max int) (int, error) {
+	value := c.Query[name]
+	if value == "" {
+		return 0, NewValidationError(name, "is required")
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, &ValidationError{Field: name, Message: "must be an integer", err: err}
+	}
+	if n < min || n > max {
+		return 0, &ValidationError{Field: name, Message: fmt.Sprintf("must be between %d and %d", min, max), err: strconv.ErrRange}
+	}
+	return n, nil
+}
+
+func (c *Context) QueryBool(name string) (bool, error) {
+	value := c.Query[name]
+	if value == "" {
+		return false, fmt.Errorf("parameter %s not found", name)
+	}
+	return strconv.ParseBool(value)
+}
+
+func (c *Context) QueryBoolDefault(name string, defaultValue bool) bool {
+	if value, err := c.QueryBool(name); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func (c *Context) QueryFloat(name string) (float64, error) {
+	value := c.Query[name]
+	if value == "" {
+		return 0, fmt.Errorf("parameter %s not found", name)
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+func (c *Context) QueryTime(name, layout string) (time.Time, error) {
+	value := c.Query[name]
+	if value == "" {
+		return time.Time{}, fmt.Errorf("parameter %s not found", name)
+	}
+	return time.Parse(layout, value)
+}
+
 func (c *Context) IsJSON() bool {
-	return c.Request.Header.Get("Content-Type") == "application/json"
+	return mediaType(c.Request.Header.Get("Content-Type")) == "application/json"
+}
+
+// IsForm reports whether the request body is urlencoded form data.
+func (c *Context) IsForm() bool {
+	return mediaType(c.Request.Header.Get("Content-Type")) == "application/x-www-form-urlencoded"
 }
 
 func (c *Context) IsAjax() bool {
 	return c.Request.Header.Get("X-Requested-With") == "XMLHttpRequest"
 }
 
+// ClientIP returns the client address, as GetRealIPFor does for the
+// router's TrustedPlatform. AccessLog, SlowLog and RateLimit all use it.
 func (c *Context) ClientIP() string {
-	if xff := c.Request.Header.Get("X-Forwarded-For"); xff != "" {
-		return xff
+	platform := ""
+	if c.router != nil {
+		platform = c.router.platform
+	}
+	return GetRealIPFor(c.Request, platform)
+}
+
+// NewContext creates a Context for req outside of a Router, so handlers
+// can be called directly in unit tests. Set path parameters on Params.
+// Router settings such as FieldNaming don't apply to it.
+func NewContext(w http.ResponseWriter, req *http.Request) *Context {
+	rw := &responseWriter{ResponseWriter: w}
+	query := make(map[string]string)
+	for k, v := range req.URL.Query() {
+		if len(v) > 0 {
+			query[k] = v[0]
+		}
+	}
+	return &Context{
+		Request:  req,
+		Writer:   rw,
+		Response: rw,
+		Params:   make(map[string]string),
+		Query:    query,
+	}
+}
+
+// RequestContext returns the request's context.Context. Pass it to database
+// calls and outgoing requests so they are cancelled when the client goes away
+// or a Timeout middleware fires.
+func (c *Context) RequestContext() context.Context {
+	return c.Request.Context()
+}
+
+// PropagatedHeaders are the headers PropagateHeaders copies by default: the
+// request id and the W3C trace context.
+var PropagatedHeaders = []string{"X-Request-ID", "traceparent", "tracestate"}
+
+// PropagateHeaders copies the named headers of the incoming request onto
+// an outgoing one, defaulting to PropagatedHeaders, so downstream services
+// log the same request id and join the same trace. A request id set on the
+// response by middleware takes precedence over the client's.
+func (c *Context) PropagateHeaders(req *http.Request, names ...string) {
+	if len(names) == 0 {
+		names = PropagatedHeaders
+	}
+	for _, name := range names {
+		value := c.Request.Header.Get(name)
+		if http.CanonicalHeaderKey(name) == "X-Request-Id" {
+			value = requestIDOf(c)
+		}
+		if value != "" {
+			req.Header.Set(name, value)
+		}
+	}
+}
+
+// ErrUnsafePath is returned by SafePath when the wildcard path would escape
+// the base directory.
+var ErrUnsafePath = errors.New("routix: path escapes base directory")
+
+// SafePath joins the route's * wildcard onto base and verifies the result
+// stays inside base, rejecting traversal such as ../../etc/passwd. Use it
+// whenever a wildcard ends up on the filesystem.
+func (c *Context) SafePath(base string) (string, error) {
+	name := c.Params["*"]
+	if strings.ContainsRune(name, 0) || strings.Contains(name, "\\") {
+		return "", ErrUnsafePath
 	}
-	if xri := c.Request.Header.Get("X-Real-IP"); xri != "" {
-		return xri
+
+	root, err := filepath.Abs(base)
+	if err != nil {
+		return "", err
+	}
+	full := filepath.Join(root, filepath.FromSlash(name))
+	rel, err := filepath.Rel(root, full)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", ErrUnsafePath
 	}
-	return c.Request.RemoteAddr
+	return full, nil
 }
 
 func (c *Context) UserAgent() string {
 	return c.Request.Header.Get("User-Agent")
-}
\ No newline at end of file
+}
+
+// LocaleKey is the context key under which Locale stores the negotiated locale.
+const LocaleKey = "routix.locale"
+
+// Locale negotiates the best match for the Accept-Language header among the
+// supported locales, honouring quality values. A region-specific tag such as
+// en-GB falls back to a supported base language (en). The result is stored
+// under LocaleKey so later middleware, validation and templates can read it.
+func (c *Context) Locale(supported []string, def string) string {
+	locale := def
+	for _, tag := range parseAcceptLanguage(c.Request.Header.Get("Accept-Language")) {
+		if match := matchLocale(tag, supported); match != "" {
+			locale = match
+			break
+		}
+	}
+	c.Set(LocaleKey, locale)
+	return locale
+}
+
+type languageRange struct {
+	tag string
+	q   float64
+}
+
+// parseAcceptLanguage returns the language tags in preference order,
+// dropping ranges with q=0.
+func parseAcceptLanguage(header string) []string {
+	var ranges []languageRange
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		lr := languageRange{tag: part, q: 1}
+		if i := strings.Index(part, ";"); i != -1 {
+			lr.tag = strings.TrimSpace(part[:i])
+			param := strings.TrimSpace(part[i+1:])
+			if strings.HasPrefix(param, "q=") {
+				q, err := strconv.ParseFloat(param[2:], 64)
+				if err != nil {
+					continue
+				}
+				lr.q = q
+			}
+		}
+		if lr.q <= 0 {
+			continue
+		}
+		ranges = append(ranges, lr)
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	tags := make([]string, len(ranges))
+	for i, lr := range ranges {
+		tags[i] = lr.tag
+	}
+	return tags
+}
+
+func matchLocale(tag string, supported []string) string {
+	if tag == "*" {
+		return ""
+	}
+	for _, s := range supported {
+		if strings.EqualFold(s, tag) {
+			return s
+		}
+	}
+	base := strings.SplitN(tag, "-", 2)[0]
+	for _, s := range supported {
+		if strings.EqualFold(strings.SplitN(s, "-", 2)[0], base) {
+			return s
+		}
+	}
+	return ""
+}
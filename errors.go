@@ -3,7 +3,9 @@
 package routix
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"runtime"
 	"strings"
@@ -14,6 +16,8 @@ import (
 type ValidationError struct {
 	Field   string `json:"field"`
 	Message string `json:"message"`
+
+	err error // cause, if any, such as strconv.ErrRange
 }
 
 // Error implements the error interface for ValidationError.
@@ -22,6 +26,8 @@ func (e *ValidationError) Error() string {
 	return fmt.Sprintf("%s: %s", e.Field, e.Message)
 }
 
+func (e *ValidationError) Unwrap() error { return e.err }
+
 // ValidationErrors represents multiple validation errors
 type ValidationErrors []*ValidationError
 
@@ -70,6 +76,21 @@ func GetHTTPStatusCode(err error) int {
 	if _, ok := err.(*ValidationError); ok {
 		return http.StatusBadRequest
 	}
+	if errors.Is(err, ErrMissingParam) {
+		return http.StatusNotFound
+	}
+	if _, ok := err.(*ParamError); ok {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, ErrEmptyBody) || errors.Is(err, ErrUnsafePath) || errors.Is(err, ErrJSONTooDeep) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, ErrBodyTooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	if errors.Is(err, ErrUnsupportedContentType) {
+		return http.StatusUnsupportedMediaType
+	}
 	return http.StatusInternalServerError
 }
 
@@ -157,10 +178,22 @@ func MethodNotAllowed(message string, err error) *Error {
 	return NewError(405, message, err)
 }
 
+func UnsupportedMediaType(message string, err error) *Error {
+	return NewError(415, message, err)
+}
+
+func UnprocessableEntity(message string, err error) *Error {
+	return NewError(422, message, err)
+}
+
 func InternalServerError(message string, err error) *Error {
 	return NewError(500, message, err)
 }
 
+func ServiceUnavailable(message string, err error) *Error {
+	return NewError(503, message, err)
+}
+
 // ErrorResponse represents the structure of error responses
 type ErrorResponse struct {
 	Code    int    `json:"code"`
@@ -169,8 +202,18 @@ type ErrorResponse struct {
 	Stack   string `json:"stack,omitempty"`
 }
 
-// ToResponse converts an Error to an ErrorResponse
+// StackTraceMinStatus is the lowest status whose error responses carry the
+// stack trace, and then only in dev mode. Client errors never expose it.
+var StackTraceMinStatus = http.StatusInternalServerError
+
+// ToResponse converts an Error to an ErrorResponse. The stack trace is
+// included only when DevMode is set and the status is at least
+// StackTraceMinStatus.
 func (e *Error) ToResponse() ErrorResponse {
+	return e.toResponse(DevMode)
+}
+
+func (e *Error) toResponse(dev bool) ErrorResponse {
 	resp := ErrorResponse{
 		Code:    e.Code,
 		Message: e.Message,
@@ -178,8 +221,17 @@ func (e *Error) ToResponse() ErrorResponse {
 	if e.Err != nil {
 		resp.Error = e.Err.Error()
 	}
-	if e.Stack != "" {
+	if dev && e.Code >= StackTraceMinStatus && e.Stack != "" {
 		resp.Stack = e.Stack
 	}
 	return resp
 }
+
+// errorResponse converts e for the client, including the stack trace when
+// the router is in dev mode. Stacks of server errors are always logged.
+func (c *Context) errorResponse(e *Error) ErrorResponse {
+	if e.Code >= StackTraceMinStatus && e.Stack != "" {
+		log.Printf("routix: %s %s: %v\n%s", c.Request.Method, c.Request.URL.Path, e, e.Stack)
+	}
+	return e.toResponse(DevMode || (c.router != nil && c.router.devMode))
+}
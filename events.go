@@ -0,0 +1,37 @@
+package routix
+
+// On subscribes fn to event, e.g. "user.created". Handlers and middleware
+// publish events with Emit; fn runs on its own goroutine for every emit,
+// so it must be safe for concurrent use.
+func (r *Router) On(event string, fn func(payload interface{})) *Router {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.listeners == nil {
+		r.listeners = make(map[string][]func(payload interface{}))
+	}
+	r.listeners[event] = append(r.listeners[event], fn)
+	return r
+}
+
+// Emit publishes payload to the subscribers of event without waiting for
+// them, so slow consumers such as audit logs never delay the response.
+// Subscribers that panic are recovered and otherwise ignored.
+func (r *Router) Emit(event string, payload interface{}) {
+	r.mu.RLock()
+	listeners := r.listeners[event]
+	r.mu.RUnlock()
+
+	for _, fn := range listeners {
+		go func(fn func(payload interface{})) {
+			defer func() { recover() }()
+			fn(payload)
+		}(fn)
+	}
+}
+
+// Emit publishes an event on the router handling the request.
+func (c *Context) Emit(event string, payload interface{}) {
+	if c.router != nil {
+		c.router.Emit(event, payload)
+	}
+}
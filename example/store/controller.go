@@ -6,11 +6,11 @@ import (
 
 // StoreController handles store-related requests
 type StoreController struct {
-	storeService *StoreService
+	storeService ProductService
 }
 
 // NewStoreController creates a new store controller
-func NewStoreController(storeService *StoreService) *StoreController {
+func NewStoreController(storeService ProductService) *StoreController {
 	return &StoreController{
 		storeService: storeService,
 	}
@@ -28,7 +28,7 @@ func (c *StoreController) Register(r *routix.Router) {
 
 // GetProducts handles GET /store/products
 func (c *StoreController) GetProducts(ctx *routix.Context) error {
-	products, err := c.storeService.GetProducts()
+	products, err := c.storeService.GetProducts(ctx.RequestContext())
 	if err != nil {
 		return ctx.Error(err, "Failed to get products")
 	}
@@ -42,7 +42,7 @@ func (c *StoreController) CreateProduct(ctx *routix.Context) error {
 		return ctx.Error(err, "Invalid product data")
 	}
 
-	if err := c.storeService.CreateProduct(&product); err != nil {
+	if err := c.storeService.CreateProduct(ctx.RequestContext(), &product); err != nil {
 		return ctx.Error(err, "Failed to create product")
 	}
 
@@ -52,7 +52,7 @@ func (c *StoreController) CreateProduct(ctx *routix.Context) error {
 // GetProduct handles GET /store/products/:id
 func (c *StoreController) GetProduct(ctx *routix.Context) error {
 	id := ctx.Params["id"]
-	product, err := c.storeService.GetProduct(id)
+	product, err := c.storeService.GetProduct(ctx.RequestContext(), id)
 	if err != nil {
 		return ctx.Error(err, "Product not found")
 	}
@@ -67,7 +67,7 @@ func (c *StoreController) UpdateProduct(ctx *routix.Context) error {
 		return ctx.Error(err, "Invalid product data")
 	}
 
-	if err := c.storeService.UpdateProduct(id, &product); err != nil {
+	if err := c.storeService.UpdateProduct(ctx.RequestContext(), id, &product); err != nil {
 		return ctx.Error(err, "Failed to update product")
 	}
 
@@ -77,7 +77,7 @@ func (c *StoreController) UpdateProduct(ctx *routix.Context) error {
 // DeleteProduct handles DELETE /store/products/:id
 func (c *StoreController) DeleteProduct(ctx *routix.Context) error {
 	id := ctx.Params["id"]
-	if err := c.storeService.DeleteProduct(id); err != nil {
+	if err := c.storeService.DeleteProduct(ctx.RequestContext(), id); err != nil {
 		return ctx.Error(err, "Failed to delete product")
 	}
 	return ctx.Success(nil)
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"context"
 	"fmt"
 	"sync"
 )
@@ -14,7 +15,19 @@ type Product struct {
 	Stock       int     `json:"stock"`
 }
 
-// StoreService handles store-related business logic
+// ProductService is the store's business logic. Every method takes the
+// request's context so a timeout or a client disconnect cancels the work,
+// such as an in-flight database query.
+type ProductService interface {
+	GetProducts(ctx context.Context) ([]*Product, error)
+	GetProduct(ctx context.Context, id string) (*Product, error)
+	CreateProduct(ctx context.Context, product *Product) error
+	UpdateProduct(ctx context.Context, id string, product *Product) error
+	DeleteProduct(ctx context.Context, id string) error
+}
+
+// StoreService is an in-memory ProductService. A database-backed service
+// would pass ctx on to QueryContext/ExecContext instead of checking it.
 type StoreService struct {
 	products map[string]*Product
 	mu       sync.RWMutex
@@ -28,7 +41,11 @@ func NewStoreService() *StoreService {
 }
 
 // GetProducts returns all products
-func (s *StoreService) GetProducts() ([]*Product, error) {
+func (s *StoreService) GetProducts(ctx context.Context) ([]*Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -40,7 +57,11 @@ func (s *StoreService) GetProducts() ([]*Product, error) {
 }
 
 // GetProduct returns a product by ID
-func (s *StoreService) GetProduct(id string) (*Product, error) {
+func (s *StoreService) GetProduct(ctx context.Context, id string) (*Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -52,7 +73,11 @@ func (s *StoreService) GetProduct(id string) (*Product, error) {
 }
 
 // CreateProduct creates a new product
-func (s *StoreService) CreateProduct(product *Product) error {
+func (s *StoreService) CreateProduct(ctx context.Context, product *Product) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -65,7 +90,11 @@ func (s *StoreService) CreateProduct(product *Product) error {
 }
 
 // UpdateProduct updates an existing product
-func (s *StoreService) UpdateProduct(id string, product *Product) error {
+func (s *StoreService) UpdateProduct(ctx context.Context, id string, product *Product) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -79,7 +108,11 @@ func (s *StoreService) UpdateProduct(id string, product *Product) error {
 }
 
 // DeleteProduct deletes a product
-func (s *StoreService) DeleteProduct(id string) error {
+func (s *StoreService) DeleteProduct(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
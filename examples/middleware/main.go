@@ -26,6 +26,8 @@ func APIKey(key string) routix.Middleware {
 			if apiKey != key {
 				return c.Error(fmt.Errorf("invalid api key"), "Invalid API key")
 			}
+			// Stash the caller for handlers further down the chain
+			c.Set("client", "api-key-client")
 			return next(c)
 		}
 	}
@@ -54,6 +56,12 @@ func main() {
 	api := r.Group("/api")
 	api.Use(APIKey("secret-key"))
 	{
+		api.GET("/me", func(c *routix.Context) error {
+			return c.Success(map[string]interface{}{
+				"client": c.GetString("client"),
+			})
+		})
+
 		api.GET("/users", func(c *routix.Context) error {
 			return c.Success([]map[string]interface{}{
 				{"id": 1, "name": "Ramusa"},
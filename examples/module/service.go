@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/ramusaaa/routix"
@@ -51,7 +52,7 @@ func (c *StoreController) Register(r *routix.Router) {
 
 // GetProducts handles GET /store/products
 func (c *StoreController) GetProducts(ctx *routix.Context) error {
-	products, err := c.storeService.GetProducts()
+	products, err := c.storeService.GetProducts(ctx.RequestContext())
 	if err != nil {
 		return ctx.Error(err, "Failed to get products")
 	}
@@ -65,7 +66,7 @@ func (c *StoreController) CreateProduct(ctx *routix.Context) error {
 		return ctx.Error(err, "Invalid product data")
 	}
 
-	if err := c.storeService.CreateProduct(&product); err != nil {
+	if err := c.storeService.CreateProduct(ctx.RequestContext(), &product); err != nil {
 		return ctx.Error(err, "Failed to create product")
 	}
 
@@ -75,7 +76,7 @@ func (c *StoreController) CreateProduct(ctx *routix.Context) error {
 // GetProduct handles GET /store/products/:id
 func (c *StoreController) GetProduct(ctx *routix.Context) error {
 	id := ctx.Params["id"]
-	product, err := c.storeService.GetProduct(id)
+	product, err := c.storeService.GetProduct(ctx.RequestContext(), id)
 	if err != nil {
 		return ctx.Error(err, "Product not found")
 	}
@@ -90,7 +91,7 @@ func (c *StoreController) UpdateProduct(ctx *routix.Context) error {
 		return ctx.Error(err, "Invalid product data")
 	}
 
-	if err := c.storeService.UpdateProduct(id, &product); err != nil {
+	if err := c.storeService.UpdateProduct(ctx.RequestContext(), id, &product); err != nil {
 		return ctx.Error(err, "Failed to update product")
 	}
 
@@ -100,14 +101,17 @@ func (c *StoreController) UpdateProduct(ctx *routix.Context) error {
 // DeleteProduct handles DELETE /store/products/:id
 func (c *StoreController) DeleteProduct(ctx *routix.Context) error {
 	id := ctx.Params["id"]
-	if err := c.storeService.DeleteProduct(id); err != nil {
+	if err := c.storeService.DeleteProduct(ctx.RequestContext(), id); err != nil {
 		return ctx.Error(err, "Failed to delete product")
 	}
 	return ctx.Success(nil)
 }
 
 // GetProducts returns all products
-func (s *StoreService) GetProducts() ([]*Product, error) {
+func (s *StoreService) GetProducts(ctx context.Context) ([]*Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	products := make([]*Product, 0, len(s.products))
 	for _, product := range s.products {
 		products = append(products, product)
@@ -116,7 +120,10 @@ func (s *StoreService) GetProducts() ([]*Product, error) {
 }
 
 // GetProduct returns a product by ID
-func (s *StoreService) GetProduct(id string) (*Product, error) {
+func (s *StoreService) GetProduct(ctx context.Context, id string) (*Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	product, ok := s.products[id]
 	if !ok {
 		return nil, fmt.Errorf("product not found: %s", id)
@@ -125,7 +132,10 @@ func (s *StoreService) GetProduct(id string) (*Product, error) {
 }
 
 // CreateProduct creates a new product
-func (s *StoreService) CreateProduct(product *Product) error {
+func (s *StoreService) CreateProduct(ctx context.Context, product *Product) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	if _, ok := s.products[product.ID]; ok {
 		return fmt.Errorf("product already exists: %s", product.ID)
 	}
@@ -134,7 +144,10 @@ func (s *StoreService) CreateProduct(product *Product) error {
 }
 
 // UpdateProduct updates an existing product
-func (s *StoreService) UpdateProduct(id string, product *Product) error {
+func (s *StoreService) UpdateProduct(ctx context.Context, id string, product *Product) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	if _, ok := s.products[id]; !ok {
 		return fmt.Errorf("product not found: %s", id)
 	}
@@ -144,7 +157,10 @@ func (s *StoreService) UpdateProduct(id string, product *Product) error {
 }
 
 // DeleteProduct deletes a product
-func (s *StoreService) DeleteProduct(id string) error {
+func (s *StoreService) DeleteProduct(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	if _, ok := s.products[id]; !ok {
 		return fmt.Errorf("product not found: %s", id)
 	}
@@ -8,9 +8,12 @@ import (
 
 // Task example demonstrating background task management
 func main() {
-	// Create a new API using the builder
+	tasks := NewTaskManager()
+
+	// Create a new API using the builder. Prod() is left out because its
+	// Compress middleware buffers responses, which would hold back the
+	// progress stream.
 	app := routix.NewAPI().
-		Prod().
 		JSON().
 		CORS()
 
@@ -21,25 +24,27 @@ func main() {
 		})
 	})
 
-	// Example: Start a task (demonstrates async pattern)
+	// Start a task in the background and return its ID
 	app.POST("/tasks", func(c *routix.Context) error {
-		// In a real application, you would create a task and return its ID
-		// Task management would be handled by your application logic
-		return c.Success(map[string]any{
-			"task_id": "example-task-123",
-			"status":  "pending",
-			"message": "Task created - implement your own task management logic",
+		id := tasks.Start(simulateWork)
+		return c.Accepted(map[string]any{
+			"task_id": id,
+			"status":  "running",
 		})
 	})
 
-	// Example: Get task status
+	// Get task status
 	app.GET("/tasks/:id", func(c *routix.Context) error {
-		taskID := c.Params["id"]
-		return c.Success(map[string]any{
-			"id":      taskID,
-			"status":  "completed",
-			"message": "This is an example response - implement your own task lookup",
-		})
+		progress, ok := tasks.Status(c.Params["id"])
+		if !ok {
+			return c.NotFound("task not found")
+		}
+		return c.Success(progress)
+	})
+
+	// Stream task progress as server-sent events instead of polling
+	app.GET("/tasks/:id/progress", func(c *routix.Context) error {
+		return tasks.StreamProgress(c, c.Params["id"])
 	})
 
 	// Start the server
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ramusaaa/routix"
+)
+
+// Progress is one update of a task's state.
+type Progress struct {
+	TaskID  string `json:"task_id"`
+	Status  string `json:"status"` // running, completed or failed
+	Percent int    `json:"percent"`
+	Error   string `json:"error,omitempty"`
+}
+
+func (p Progress) done() bool {
+	return p.Status == "completed" || p.Status == "failed"
+}
+
+// TaskManager runs background tasks and fans their progress out to
+// subscribers.
+type TaskManager struct {
+	mu          sync.Mutex
+	nextID      int
+	latest      map[string]Progress
+	subscribers map[string][]chan Progress
+}
+
+func NewTaskManager() *TaskManager {
+	return &TaskManager{
+		latest:      make(map[string]Progress),
+		subscribers: make(map[string][]chan Progress),
+	}
+}
+
+// Start runs work in the background and returns the new task's id. work
+// reports progress through the given function and fails the task by
+// returning an error.
+func (m *TaskManager) Start(work func(report func(percent int)) error) string {
+	m.mu.Lock()
+	m.nextID++
+	id := fmt.Sprintf("task-%d", m.nextID)
+	m.latest[id] = Progress{TaskID: id, Status: "running"}
+	m.mu.Unlock()
+
+	go func() {
+		err := work(func(percent int) {
+			m.publish(Progress{TaskID: id, Status: "running", Percent: percent})
+		})
+		if err != nil {
+			m.publish(Progress{TaskID: id, Status: "failed", Error: err.Error()})
+			return
+		}
+		m.publish(Progress{TaskID: id, Status: "completed", Percent: 100})
+	}()
+	return id
+}
+
+// Status returns the latest progress of a task.
+func (m *TaskManager) Status(id string) (Progress, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.latest[id]
+	return p, ok
+}
+
+// Subscribe returns a channel receiving the task's current progress and
+// every later update. It is closed once the task completes or fails.
+func (m *TaskManager) Subscribe(id string) (<-chan Progress, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.latest[id]
+	if !ok {
+		return nil, false
+	}
+	ch := make(chan Progress, 16)
+	ch <- p
+	if p.done() {
+		close(ch)
+		return ch, true
+	}
+	m.subscribers[id] = append(m.subscribers[id], ch)
+	return ch, true
+}
+
+func (m *TaskManager) publish(p Progress) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.latest[p.TaskID] = p
+	for _, ch := range m.subscribers[p.TaskID] {
+		if p.done() {
+			// A slow subscriber skips progress updates but never the final
+			// event: make room by dropping its oldest update. publish is
+			// the only sender and holds mu, so the send can't block.
+			select {
+			case ch <- p:
+			default:
+				select {
+				case <-ch:
+				default:
+				}
+				ch <- p
+			}
+			close(ch)
+			continue
+		}
+		select {
+		case ch <- p:
+		default: // slow subscriber
+		}
+	}
+	if p.done() {
+		delete(m.subscribers, p.TaskID)
+	}
+}
+
+// StreamProgress pushes a task's progress to the client as server-sent
+// events until the task completes or fails, ending with a "complete" or
+// "error" event. Clients use it instead of polling GET /tasks/:id.
+func (m *TaskManager) StreamProgress(c *routix.Context, taskID string) error {
+	updates, ok := m.Subscribe(taskID)
+	if !ok {
+		return c.NotFound("task not found")
+	}
+
+	h := c.Response.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	c.Response.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(c.Response)
+
+	for {
+		select {
+		case <-c.RequestContext().Done():
+			return nil
+		case p, open := <-updates:
+			if !open {
+				return nil
+			}
+			event := "progress"
+			switch p.Status {
+			case "completed":
+				event = "complete"
+			case "failed":
+				event = "error"
+			}
+			data, err := json.Marshal(p)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(c.Response, "event: %s\ndata: %s\n\n", event, data); err != nil {
+				return err
+			}
+			rc.Flush()
+		}
+	}
+}
+
+// simulateWork stands in for a long-running job.
+func simulateWork(report func(percent int)) error {
+	for percent := 20; percent < 100; percent += 20 {
+		time.Sleep(500 * time.Millisecond)
+		report(percent)
+	}
+	return nil
+}
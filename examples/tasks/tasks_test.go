@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ramusaaa/routix"
+)
+
+func TestStreamProgress(t *testing.T) {
+	tasks := NewTaskManager()
+	r := routix.New()
+	r.GET("/tasks/:id/progress", func(c *routix.Context) error {
+		return tasks.StreamProgress(c, c.Params["id"])
+	})
+
+	subscribed := make(chan struct{})
+	id := tasks.Start(func(report func(percent int)) error {
+		<-subscribed
+		report(50)
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/tasks/"+id+"/progress", nil))
+	}()
+	for {
+		tasks.mu.Lock()
+		n := len(tasks.subscribers[id])
+		tasks.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(subscribed)
+	<-done
+
+	body := w.Body.String()
+	if !strings.Contains(body, "event: progress\ndata: {\"task_id\":\""+id+"\",\"status\":\"running\",\"percent\":50}") {
+		t.Errorf("missing progress event:\n%s", body)
+	}
+	if !strings.HasSuffix(body, "event: complete\ndata: {\"task_id\":\""+id+"\",\"status\":\"completed\",\"percent\":100}\n\n") {
+		t.Errorf("stream did not end with the complete event:\n%s", body)
+	}
+}
+
+func TestPublishDeliversFinalEventToSlowSubscriber(t *testing.T) {
+	tasks := NewTaskManager()
+	// The task waits for the test, which publishes on its behalf.
+	release := make(chan struct{})
+	defer close(release)
+	id := tasks.Start(func(report func(percent int)) error {
+		<-release
+		return nil
+	})
+	updates, _ := tasks.Subscribe(id)
+
+	for percent := 1; percent <= 40; percent++ {
+		tasks.publish(Progress{TaskID: id, Status: "running", Percent: percent})
+	}
+	tasks.publish(Progress{TaskID: id, Status: "failed", Error: "boom"})
+
+	var last Progress
+	for p := range updates {
+		last = p
+	}
+	if last.Status != "failed" || last.Error != "boom" {
+		t.Fatalf("expected the final failed event, got %+v", last)
+	}
+}
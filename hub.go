@@ -0,0 +1,121 @@
+package routix
+
+import "sync"
+
+// HubConn is a connection a Hub can deliver messages to. routix has no
+// WebSocket upgrade of its own; adapt the connection type of your WebSocket
+// library, e.g. a wrapper calling conn.WriteMessage(websocket.TextMessage,
+// data) for gorilla/websocket.
+type HubConn interface {
+	WriteMessage(data []byte) error
+}
+
+// DefaultHubQueueSize is the per-connection send queue length used when
+// NewHub is given a size below one.
+const DefaultHubQueueSize = 16
+
+// Hub fans messages out to a set of connections, as in chat rooms and
+// notification feeds. Every connection has its own send queue and writer
+// goroutine, so one slow client doesn't hold up the others; a client whose
+// queue is full, or whose write fails, is unregistered.
+type Hub struct {
+	mu        sync.Mutex
+	clients   map[HubConn]*hubClient
+	queueSize int
+}
+
+// hubClient is one registration of a connection. done is closed when it is
+// unregistered, so its writer stops even with messages still queued.
+type hubClient struct {
+	send chan []byte
+	done chan struct{}
+}
+
+// NewHub creates a Hub whose connections queue up to queueSize messages.
+func NewHub(queueSize int) *Hub {
+	if queueSize < 1 {
+		queueSize = DefaultHubQueueSize
+	}
+	return &Hub{
+		clients:   make(map[HubConn]*hubClient),
+		queueSize: queueSize,
+	}
+}
+
+// Register adds conn to the hub and starts delivering broadcasts to it.
+func (h *Hub) Register(conn HubConn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[conn]; ok {
+		return
+	}
+	client := &hubClient{
+		send: make(chan []byte, h.queueSize),
+		done: make(chan struct{}),
+	}
+	h.clients[conn] = client
+	go h.writeLoop(conn, client)
+}
+
+// Unregister removes conn from the hub. Messages still queued for it are
+// dropped. It does not close the underlying connection.
+func (h *Hub) Unregister(conn HubConn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if client, ok := h.clients[conn]; ok {
+		delete(h.clients, conn)
+		close(client.done)
+	}
+}
+
+// Broadcast queues msg for every registered connection without waiting
+// for any of them to write it.
+func (h *Hub) Broadcast(msg []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for conn, client := range h.clients {
+		select {
+		case client.send <- msg:
+		default:
+			delete(h.clients, conn)
+			close(client.done)
+		}
+	}
+}
+
+// Len returns the number of registered connections.
+func (h *Hub) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
+
+func (h *Hub) writeLoop(conn HubConn, client *hubClient) {
+	for {
+		select {
+		case <-client.done:
+			return
+		case msg := <-client.send:
+			// Both cases may be ready; don't write once unregistered.
+			select {
+			case <-client.done:
+				return
+			default:
+			}
+			if err := conn.WriteMessage(msg); err != nil {
+				h.drop(conn, client)
+				return
+			}
+		}
+	}
+}
+
+// drop unregisters client, unless conn has since been registered again.
+func (h *Hub) drop(conn HubConn, client *hubClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.clients[conn] == client {
+		delete(h.clients, conn)
+		close(client.done)
+	}
+}
@@ -35,15 +35,72 @@ package routix
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
+// namedMiddleware pairs a registered middleware with the name route
+// listings report for it.
+type namedMiddleware struct {
+	name string
+	mw   Middleware
+}
+
+func nameMiddleware(middleware []Middleware) []namedMiddleware {
+	named := make([]namedMiddleware, len(middleware))
+	for i, mw := range middleware {
+		named[i] = namedMiddleware{MiddlewareName(mw), mw}
+	}
+	return named
+}
+
+func middlewareNames(middleware []namedMiddleware) []string {
+	names := make([]string, len(middleware))
+	for i, m := range middleware {
+		names[i] = m.name
+	}
+	return names
+}
+
+// MiddlewareName returns a name for mw derived from the function that built
+// it, such as "routix.Logger". Register middleware with UseNamed to report
+// a name of your choice instead.
+func MiddlewareName(mw Middleware) string {
+	if mw == nil {
+		return "<nil>"
+	}
+
+	pc := reflect.ValueOf(mw).Pointer()
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return "<unknown>"
+	}
+	name := fn.Name()
+	if i := strings.LastIndex(name, "/"); i != -1 {
+		name = name[i+1:]
+	}
+	// Drop closure suffixes: routix.Logger.func1 -> routix.Logger.
+	for {
+		i := strings.LastIndex(name, ".func")
+		if i == -1 {
+			break
+		}
+		name = name[:i]
+	}
+	return name
+}
+
 // Logger returns a middleware that logs request method, path, status code, and latency.
 func Logger() Middleware {
 	return func(next Handler) Handler {
@@ -89,7 +146,7 @@ func ErrorHandler() Middleware {
 			}
 
 			// Convert error to response
-			resp := routixErr.ToResponse()
+			resp := c.errorResponse(routixErr)
 
 			// Set content type
 			c.Response.Header().Set("Content-Type", "application/json")
@@ -101,10 +158,23 @@ func ErrorHandler() Middleware {
 	}
 }
 
+// RecoverPanics controls whether Recovery, RecoverToError and a router's
+// PanicHandler recover panics. Set it to false in tests to let panics
+// propagate out of ServeHTTP; Router.DisableRecovery does the same for a
+// single router.
+var RecoverPanics = true
+
+func (c *Context) recoverPanics() bool {
+	return RecoverPanics && (c.router == nil || !c.router.noRecover)
+}
+
 // Recovery is a middleware that recovers from panics
 func Recovery() Middleware {
 	return func(next Handler) Handler {
 		return func(c *Context) error {
+			if !c.recoverPanics() {
+				return next(c)
+			}
 			defer func() {
 				if r := recover(); r != nil {
 					var err error
@@ -126,7 +196,7 @@ func Recovery() Middleware {
 					}
 
 					// Convert error to response
-					resp := routixErr.ToResponse()
+					resp := c.errorResponse(routixErr)
 
 					// Set content type
 					c.Response.Header().Set("Content-Type", "application/json")
@@ -142,8 +212,35 @@ func Recovery() Middleware {
 	}
 }
 
+// RecoverToError is like Recovery but returns the panic as a 500 *Error
+// instead of writing a response, so ErrorHandler or the router's error
+// mapping formats it like any other error.
+func RecoverToError() Middleware {
+	return func(next Handler) Handler {
+		return func(c *Context) (err error) {
+			if !c.recoverPanics() {
+				return next(c)
+			}
+			defer func() {
+				if r := recover(); r != nil {
+					switch x := r.(type) {
+					case error:
+						err = InternalServerError("Internal Server Error", x)
+					default:
+						err = InternalServerError("Internal Server Error", fmt.Errorf("%v", x))
+					}
+				}
+			}()
+
+			return next(c)
+		}
+	}
+}
+
 // CORS returns a middleware that handles Cross-Origin Resource Sharing.
-// It sets appropriate CORS headers for cross-origin requests.
+// It sets appropriate CORS headers for cross-origin requests. Preflight
+// responses advertise only the methods registered for the requested path,
+// as reported by Router.AllowedMethods.
 func CORS() Middleware {
 	return func(next Handler) Handler {
 		return func(c *Context) error {
@@ -153,6 +250,11 @@ func CORS() Middleware {
 
 			// Handle preflight requests
 			if c.Request.Method == "OPTIONS" {
+				if c.router != nil {
+					if allowed := c.router.AllowedMethods(c.Request.URL.Path); len(allowed) > 0 {
+						c.Response.Header().Set("Access-Control-Allow-Methods", strings.Join(append(allowed, http.MethodOptions), ", "))
+					}
+				}
 				c.Response.WriteHeader(http.StatusOK)
 				return nil
 			}
@@ -165,15 +267,35 @@ func CORS() Middleware {
 // Auth returns a middleware that handles authentication.
 // It checks for a valid Authorization header and validates the token.
 func Auth(validateToken func(string) bool) Middleware {
+	return AuthWithOptions(validateToken, AuthOptions{})
+}
+
+// AuthOptions configures AuthWithOptions.
+type AuthOptions struct {
+	// RedirectURL, when set, sends unauthenticated requests to this URL with
+	// 302 Found instead of answering with an error.
+	RedirectURL string
+}
+
+// AuthWithOptions is Auth with its failure response configurable:
+//
+//	app.Use(routix.AuthWithOptions(checkSession, routix.AuthOptions{RedirectURL: "/login"}))
+func AuthWithOptions(validateToken func(string) bool, opts AuthOptions) Middleware {
 	return func(next Handler) Handler {
 		return func(c *Context) error {
 			header := c.Request.Header.Get("Authorization")
 			if header == "" {
+				if opts.RedirectURL != "" {
+					return c.AbortRedirect(http.StatusFound, opts.RedirectURL)
+				}
 				return c.Error(fmt.Errorf("unauthorized"), "Authentication required")
 			}
 
 			token := strings.TrimPrefix(header, "Bearer ")
 			if !validateToken(token) {
+				if opts.RedirectURL != "" {
+					return c.AbortRedirect(http.StatusFound, opts.RedirectURL)
+				}
 				return c.Error(fmt.Errorf("invalid token"), "Invalid authentication token")
 			}
 
@@ -182,8 +304,61 @@ func Auth(validateToken func(string) bool) Middleware {
 	}
 }
 
+// RequireHeaders returns a middleware that rejects requests missing any of
+// the named headers with 400 Bad Request.
+func RequireHeaders(names ...string) Middleware {
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			for _, name := range names {
+				if c.Request.Header.Get(name) == "" {
+					return BadRequest(fmt.Sprintf("missing required header: %s", name), nil)
+				}
+			}
+			return next(c)
+		}
+	}
+}
+
+// RequireContentType returns a middleware that rejects requests carrying a
+// body whose media type is not one of types with 415 Unsupported Media Type.
+// Parameters such as charset are ignored when matching.
+func RequireContentType(types ...string) Middleware {
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			if c.Request.ContentLength == 0 {
+				return next(c)
+			}
+
+			mediaType, _, err := mime.ParseMediaType(c.Request.Header.Get("Content-Type"))
+			if err == nil {
+				for _, t := range types {
+					if strings.EqualFold(mediaType, t) {
+						return next(c)
+					}
+				}
+			}
+
+			return UnsupportedMediaType(fmt.Sprintf("content-type must be one of: %s", strings.Join(types, ", ")), nil)
+		}
+	}
+}
+
+// Deprecated returns a middleware that marks every response from the routes
+// it wraps as deprecated. See Context.Deprecate.
+func Deprecated(sunset time.Time, link string) Middleware {
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			c.Deprecate(sunset, link)
+			return next(c)
+		}
+	}
+}
+
 // RateLimit returns a middleware that implements rate limiting.
-// It limits the number of requests from a single IP address.
+// It limits the number of requests from a single IP address to requests per
+// sliding window of duration. The address is Context.ClientIP, so every
+// connection from one client counts against the same limit; set
+// Router.TrustedPlatform to tell clients behind a proxy apart.
 func RateLimit(requests int, duration time.Duration) Middleware {
 	type entry struct {
 		timestamps []time.Time
@@ -193,7 +368,7 @@ func RateLimit(requests int, duration time.Duration) Middleware {
 
 	return func(next Handler) Handler {
 		return func(c *Context) error {
-			ip := c.Request.RemoteAddr
+			ip := c.ClientIP()
 
 			mu.Lock()
 			e, ok := limiter[ip]
@@ -224,11 +399,45 @@ func RateLimit(requests int, duration time.Duration) Middleware {
 	}
 }
 
+// ConcurrencyLimit returns a middleware that sheds load by allowing at most
+// max requests through at once. Requests beyond that are not queued: they
+// get 503 Service Unavailable with Retry-After: 1 straight away. It panics
+// if max is less than 1, which would reject every request.
+func ConcurrencyLimit(max int) Middleware {
+	if max < 1 {
+		panic(fmt.Sprintf("routix: ConcurrencyLimit needs max of at least 1, got %d", max))
+	}
+	sem := make(chan struct{}, max)
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			select {
+			case sem <- struct{}{}:
+			default:
+				// Answer directly: building an *Error captures a stack trace
+				// and 5xx errors are logged, both too costly under overload.
+				c.SetHeader("Retry-After", "1")
+				return c.envelope(http.StatusServiceUnavailable, ErrorResponse{
+					Code:    http.StatusServiceUnavailable,
+					Message: "Server is busy, try again shortly",
+				})
+			}
+			defer func() { <-sem }()
+			return next(c)
+		}
+	}
+}
+
 // Timeout returns a middleware that adds a timeout to request processing.
 // It cancels the request if it takes longer than the specified duration.
 func Timeout(timeout time.Duration) Middleware {
 	return func(next Handler) Handler {
 		return func(c *Context) error {
+			// Put the deadline on the request context so work started with
+			// c.RequestContext() is cancelled when the request times out.
+			ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+			defer cancel()
+			c.Request = c.Request.WithContext(ctx)
+
 			// Create a channel for the response
 			done := make(chan error, 1)
 
@@ -241,13 +450,68 @@ func Timeout(timeout time.Duration) Middleware {
 			select {
 			case err := <-done:
 				return err
-			case <-time.After(timeout):
+			case <-ctx.Done():
 				return c.Error(fmt.Errorf("request timeout"), "Request timed out")
 			}
 		}
 	}
 }
 
+// WithTimeout wraps a single handler so its request context expires after
+// d. Unlike Timeout it runs the handler on the calling goroutine, so the
+// handler must watch c.Request.Context() to stop early. If the deadline
+// passes before the handler starts its response, the request gets 503 and
+// anything the handler writes afterwards is discarded. Router.HandleTimeout
+// registers a route this way; Router.WithTimeout instead applies Timeout to
+// every route.
+//
+//	r.GET("/report", routix.WithTimeout(2*time.Second, buildReport))
+func WithTimeout(d time.Duration, handler Handler) Handler {
+	return func(c *Context) error {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+		req, rw := c.Request, c.Writer
+		late := &responseWriter{ResponseWriter: &deadlineWriter{ResponseWriter: rw, ctx: ctx}}
+		c.Request, c.Writer, c.Response = req.WithContext(ctx), late, late
+		defer func() { c.Request, c.Writer, c.Response = req, rw, rw }()
+
+		err := handler(c)
+		if ctx.Err() == context.DeadlineExceeded && !rw.written {
+			return ServiceUnavailable("Request timed out", ctx.Err())
+		}
+		return err
+	}
+}
+
+// deadlineWriter drops a response started after ctx ends, so a handler
+// that overran its deadline can't write over the timeout reply.
+type deadlineWriter struct {
+	http.ResponseWriter
+	ctx     context.Context
+	started bool
+}
+
+func (w *deadlineWriter) WriteHeader(code int) {
+	if w.started || w.ctx.Err() == nil {
+		w.started = true
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *deadlineWriter) Write(b []byte) (int, error) {
+	if !w.started && w.ctx.Err() != nil {
+		return 0, w.ctx.Err()
+	}
+	w.started = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *deadlineWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok && w.started {
+		f.Flush()
+	}
+}
+
 // Validate validates the request body or query parameters against a struct
 func Validate(v interface{}) Middleware {
 	return func(next Handler) Handler {
@@ -258,7 +522,8 @@ func Validate(v interface{}) Middleware {
 
 			validator := NewValidator()
 			if !validator.Validate(v) {
-				return c.Error(fmt.Errorf("validation failed: %v", validator.Errors()), "Validation failed")
+				errs := ValidationErrors(convertToValidationErrors(validator.Errors()))
+				return c.Error(fmt.Errorf("validation failed: %w", errs), "Validation failed")
 			}
 
 			return next(c)
@@ -266,16 +531,140 @@ func Validate(v interface{}) Middleware {
 	}
 }
 
-// Cache caches responses for a specified duration
+// ValidationSource names the part of the request ValidateSource binds.
+type ValidationSource string
+
+const (
+	SourceJSON   ValidationSource = "json"   // the JSON body, matched by json tags
+	SourceQuery  ValidationSource = "query"  // the URL query, as BindQuery
+	SourceParams ValidationSource = "params" // path parameters, as BindUri
+)
+
+// ValidatedKey is the context key under which ValidateSource stores the
+// bound value.
+const ValidatedKey = "routix.validated"
+
+// ValidateQuery is ValidateSource(v, SourceQuery).
+func ValidateQuery(v interface{}) Middleware {
+	return ValidateSource(v, SourceQuery)
+}
+
+// ValidateParams is ValidateSource(v, SourceParams).
+func ValidateParams(v interface{}) Middleware {
+	return ValidateSource(v, SourceParams)
+}
+
+// ValidateSource returns a middleware that binds source into a new value of
+// the type v points to and checks its validate tags. v only supplies the
+// type; each request gets its own value, stored under ValidatedKey:
+//
+//	r.GET("/search", routix.ValidateQuery(&searchParams{})(func(c *routix.Context) error {
+//		p := c.MustGet(routix.ValidatedKey).(*searchParams)
+//		...
+//	}))
+//
+// Malformed JSON yields 400; values that don't parse or fail validation
+// yield a 422 *Error wrapping ValidationErrors.
+func ValidateSource(v interface{}, source ValidationSource) Middleware {
+	typ := reflect.TypeOf(v)
+	if typ == nil || typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("routix: ValidateSource needs a pointer to a struct, got %T", v))
+	}
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			dst := reflect.New(typ.Elem()).Interface()
+			var err error
+			switch source {
+			case SourceQuery:
+				err = c.BindQuery(dst)
+			case SourceParams:
+				err = validateBound(c.BindUri(dst), dst, "Invalid path parameters")
+			default:
+				if err := c.ParseJSON(dst); err != nil {
+					return BadRequest("Invalid JSON body", err)
+				}
+				err = validateBound(nil, dst, "Validation failed")
+			}
+			if err != nil {
+				return err
+			}
+			c.Set(ValidatedKey, dst)
+			return next(c)
+		}
+	}
+}
+
+// validateBound turns a binding error, or failed validate tags on v, into
+// a 422 *Error with the given message.
+func validateBound(bindErr error, v interface{}, message string) error {
+	if bindErr != nil {
+		if ve, ok := bindErr.(*ValidationError); ok {
+			return UnprocessableEntity(message, ValidationErrors{ve})
+		}
+		return bindErr
+	}
+	validator := NewValidator()
+	if !validator.Validate(v) {
+		return UnprocessableEntity(message, ValidationErrors(convertToValidationErrors(validator.Errors())))
+	}
+	return nil
+}
+
+// nextWithWriter runs next on a copy of c writing to rw, as Cache and
+// Compress do to capture the response. State the handler creates on the
+// copy, such as values from a first Set or an abort, is copied back so
+// middleware outside still sees it.
+func nextWithWriter(c *Context, rw *responseWriter, next Handler) error {
+	inner := *c
+	inner.Writer, inner.Response = rw, rw
+	err := next(&inner)
+	c.Body, c.values, c.bodyBuf, c.form = inner.Body, inner.values, inner.bodyBuf, inner.form
+	c.raw, c.aborted = inner.raw, inner.aborted
+	return err
+}
+
+// CacheMaxEntries caps how many responses each Cache middleware keeps.
+// When it is full, expired entries are dropped first, then the oldest.
+var CacheMaxEntries = 1024
+
+type cacheEntry struct {
+	response []byte
+	headers  http.Header
+	code     int
+	expires  time.Time
+}
+
+// Cache caches GET responses for a specified duration, keyed by path and
+// query; query parameter order doesn't matter. Responses to requests whose
+// context was cancelled during the handler, errors and 5xx responses are
+// never cached.
 func Cache(duration time.Duration) Middleware {
-	cache := make(map[string]struct {
-		response []byte
-		headers  http.Header
-		code     int
-		expires  time.Time
-	})
+	cache := make(map[string]cacheEntry)
+	max := CacheMaxEntries
 	var mu sync.RWMutex
 
+	// store adds an entry, making room first if the cache is full. Entries
+	// share one duration, so the first to expire is the oldest.
+	store := func(key string, entry cacheEntry) {
+		mu.Lock()
+		defer mu.Unlock()
+		if _, ok := cache[key]; !ok && len(cache) >= max {
+			now := time.Now()
+			oldest := ""
+			for k, e := range cache {
+				if now.After(e.expires) {
+					delete(cache, k)
+				} else if oldest == "" || e.expires.Before(cache[oldest].expires) {
+					oldest = k
+				}
+			}
+			if len(cache) >= max {
+				delete(cache, oldest)
+			}
+		}
+		cache[key] = entry
+	}
+
 	return func(next Handler) Handler {
 		return func(c *Context) error {
 			// Only cache GET requests
@@ -283,7 +672,10 @@ func Cache(duration time.Duration) Middleware {
 				return next(c)
 			}
 
-			key := c.Request.URL.String()
+			key := c.Request.URL.Path
+			if query := c.Request.URL.Query(); len(query) > 0 {
+				key += "?" + query.Encode()
+			}
 
 			// Check cache
 			mu.RLock()
@@ -302,32 +694,24 @@ func Cache(duration time.Duration) Middleware {
 			// Capture response so we can store it in cache.
 			recorder := httptest.NewRecorder()
 			rw := &responseWriter{ResponseWriter: recorder}
-			newCtx := &Context{
-				Request:  c.Request,
-				Writer:   rw,
-				Response: rw,
-				Params:   c.Params,
-				Query:    c.Query,
-				Body:     c.Body,
+			if err := nextWithWriter(c, rw, next); err != nil {
+				return err
 			}
-
-			if err := next(newCtx); err != nil {
+			// A client that went away mid-recompute may have left a partial
+			// response; don't serve it to anyone else.
+			if err := c.Request.Context().Err(); err != nil {
 				return err
 			}
 
-			mu.Lock()
-			cache[key] = struct {
-				response []byte
-				headers  http.Header
-				code     int
-				expires  time.Time
-			}{
-				response: recorder.Body.Bytes(),
-				headers:  recorder.Header(),
-				code:     recorder.Code,
-				expires:  time.Now().Add(duration),
+			// Server errors are passed through but not cached.
+			if recorder.Code < http.StatusInternalServerError {
+				store(key, cacheEntry{
+					response: recorder.Body.Bytes(),
+					headers:  recorder.Header(),
+					code:     recorder.Code,
+					expires:  time.Now().Add(duration),
+				})
 			}
-			mu.Unlock()
 
 			for k, v := range recorder.Header() {
 				c.Response.Header()[k] = v
@@ -340,27 +724,37 @@ func Cache(duration time.Duration) Middleware {
 	}
 }
 
-// Compress compresses responses using gzip
+// BrotliEncoder returns a writer that brotli-compresses into w.
+type BrotliEncoder func(w io.Writer) io.WriteCloser
+
+var brotliEncoder BrotliEncoder
+
+// SetBrotliEncoder enables br in Compress, e.g. with
+// github.com/andybalholm/brotli:
+//
+//	routix.SetBrotliEncoder(func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) })
+//
+// Without it, Compress negotiates only gzip and identity.
+func SetBrotliEncoder(fn BrotliEncoder) {
+	brotliEncoder = fn
+}
+
+// Compress compresses responses with the best encoding the client accepts
+// according to the weighted Accept-Encoding header: br (when an encoder is
+// registered), gzip, or identity.
 func Compress() Middleware {
 	return func(next Handler) Handler {
 		return func(c *Context) error {
-			// Check if client accepts gzip
-			if !strings.Contains(c.GetHeader("Accept-Encoding"), "gzip") {
+			c.AddHeader("Vary", "Accept-Encoding")
+
+			encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"), brotliEncoder != nil)
+			if encoding == "identity" {
 				return next(c)
 			}
 
 			recorder := httptest.NewRecorder()
 			rw := &responseWriter{ResponseWriter: recorder}
-			newCtx := &Context{
-				Request:  c.Request,
-				Writer:   rw,
-				Response: rw,
-				Params:   c.Params,
-				Query:    c.Query,
-				Body:     c.Body,
-			}
-
-			if err := next(newCtx); err != nil {
+			if err := nextWithWriter(c, rw, next); err != nil {
 				return err
 			}
 
@@ -371,21 +765,25 @@ func Compress() Middleware {
 				}
 			}
 
-			// Actually compress the response with gzip
 			var buf bytes.Buffer
-			gz := gzip.NewWriter(&buf)
-			_, err := gz.Write(recorder.Body.Bytes())
+			var enc io.WriteCloser
+			if encoding == "br" {
+				enc = brotliEncoder(&buf)
+			} else {
+				enc = gzip.NewWriter(&buf)
+			}
+			_, err := enc.Write(recorder.Body.Bytes())
+			if err == nil {
+				err = enc.Close()
+			}
 			if err != nil {
 				// If compression fails, write uncompressed
 				c.Response.WriteHeader(recorder.Code)
 				c.Response.Write(recorder.Body.Bytes())
 				return nil
 			}
-			gz.Close()
 
-			// Set gzip headers
-			c.SetHeader("Content-Encoding", "gzip")
-			c.SetHeader("Vary", "Accept-Encoding")
+			c.SetHeader("Content-Encoding", encoding)
 
 			// Write status code and compressed body
 			c.Response.WriteHeader(recorder.Code)
@@ -395,3 +793,54 @@ func Compress() Middleware {
 		}
 	}
 }
+
+// negotiateEncoding picks the content coding with the highest q-value in
+// header, preferring br over gzip over identity on ties. identity is
+// acceptable unless explicitly refused, and is also the fallback when
+// nothing else is.
+func negotiateEncoding(header string, brotli bool) string {
+	q := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		if name == "" {
+			continue
+		}
+		weight := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					weight = v
+				}
+			}
+		}
+		q[name] = weight
+	}
+
+	weight := func(name string) float64 {
+		if w, ok := q[name]; ok {
+			return w
+		}
+		if w, ok := q["*"]; ok {
+			return w
+		}
+		if name == "identity" {
+			return 0.001
+		}
+		return 0
+	}
+
+	candidates := []string{"gzip", "identity"}
+	if brotli {
+		candidates = append([]string{"br"}, candidates...)
+	}
+
+	best, bestQ := "identity", 0.0
+	for _, name := range candidates {
+		if w := weight(name); w > bestQ {
+			best, bestQ = name, w
+		}
+	}
+	return best
+}
@@ -0,0 +1,149 @@
+package routix
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+)
+
+// NamingStyle controls how keys of the response and error envelopes
+// (status, timestamp, data, pageNumber, ...) are spelled. User payloads
+// inside data are never renamed.
+type NamingStyle int
+
+const (
+	// NamingPassthrough writes envelope keys exactly as routix defines them.
+	NamingPassthrough NamingStyle = iota
+	// NamingSnake writes envelope keys in snake_case, e.g. page_number.
+	NamingSnake
+	// NamingCamel writes envelope keys in camelCase, e.g. pageNumber.
+	NamingCamel
+)
+
+// FieldNaming sets the key style used for response and error envelopes.
+func (r *Router) FieldNaming(style NamingStyle) *Router {
+	r.naming = style
+	return r
+}
+
+// EnvelopeDataKey sets the key holding the payload in response and error
+// envelopes, "data" by default, for APIs that use "result" or "payload".
+// The key is written as given, regardless of FieldNaming.
+func (r *Router) EnvelopeDataKey(key string) *Router {
+	return r.envelopeKey("data", key)
+}
+
+// EnvelopeStatusKey sets the key of the envelope status, "status" by default.
+func (r *Router) EnvelopeStatusKey(key string) *Router {
+	return r.envelopeKey("status", key)
+}
+
+// EnvelopeTimestampKey sets the key of the envelope timestamp, "timestamp"
+// by default.
+func (r *Router) EnvelopeTimestampKey(key string) *Router {
+	return r.envelopeKey("timestamp", key)
+}
+
+func (r *Router) envelopeKey(name, key string) *Router {
+	if r.envKeys == nil {
+		r.envKeys = make(map[string]string)
+	}
+	r.envKeys[name] = key
+	return r
+}
+
+func (c *Context) namingStyle() NamingStyle {
+	if c.router == nil {
+		return NamingPassthrough
+	}
+	return c.router.naming
+}
+
+// envelope writes an envelope built by routix, renaming its top-level keys
+// and the keys of the nested framework-owned objects named in nested.
+func (c *Context) envelope(status int, v interface{}, nested ...string) error {
+	style := c.namingStyle()
+	var keys map[string]string
+	if c.router != nil {
+		keys = c.router.envKeys
+	}
+	if style == NamingPassthrough && keys == nil {
+		return c.JSON(status, v)
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &m); err != nil {
+		// Not an object; there are no keys to rename.
+		return c.JSON(status, v)
+	}
+
+	out := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		var renamed interface{} = value
+		for _, name := range nested {
+			if key != name {
+				continue
+			}
+			var inner map[string]json.RawMessage
+			if json.Unmarshal(value, &inner) == nil {
+				obj := make(map[string]json.RawMessage, len(inner))
+				for k, v := range inner {
+					obj[style.apply(k)] = v
+				}
+				renamed = obj
+			}
+		}
+		if custom, ok := keys[key]; ok {
+			out[custom] = renamed
+		} else {
+			out[style.apply(key)] = renamed
+		}
+	}
+	return c.JSON(status, out)
+}
+
+func (s NamingStyle) apply(key string) string {
+	switch s {
+	case NamingSnake:
+		return toSnakeCase(key)
+	case NamingCamel:
+		return toCamelCase(key)
+	default:
+		return key
+	}
+}
+
+func toSnakeCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func toCamelCase(s string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range s {
+		if r == '_' {
+			upper = b.Len() > 0
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -1,6 +1,8 @@
 package routix
 
 import (
+	"expvar"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -8,10 +10,7 @@ import (
 // Pool for reusing contexts
 var contextPool = sync.Pool{
 	New: func() interface{} {
-		return &Context{
-			Params: make(map[string]string),
-			Query:  make(map[string]string),
-		}
+		return &Context{}
 	},
 }
 
@@ -20,19 +19,11 @@ func getContext() *Context {
 	return contextPool.Get().(*Context)
 }
 
-// putContext returns a context to the pool
+// putContext returns a context to the pool. Every field is zeroed,
+// including ones added in the future, so values, buffered bodies and
+// response state never carry over to the next request.
 func putContext(c *Context) {
-	// Clear the context
-	for k := range c.Params {
-		delete(c.Params, k)
-	}
-	for k := range c.Query {
-		delete(c.Query, k)
-	}
-	c.Request = nil
-	c.Response = nil
-	c.Body = nil
-	
+	*c = Context{}
 	contextPool.Put(c)
 }
 
@@ -64,6 +55,7 @@ type Metrics struct {
 	MaxLatency      time.Duration
 	ErrorCount      int64
 	ActiveRequests  int64
+	StatusCounts    map[int]int64
 	mu              sync.RWMutex
 }
 
@@ -122,6 +114,43 @@ func (m *Metrics) GetMetrics() map[string]interface{} {
 	}
 }
 
+// recordStatus counts a completed response by status code.
+func (m *Metrics) recordStatus(status int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.StatusCounts == nil {
+		m.StatusCounts = make(map[int]int64)
+	}
+	m.StatusCounts[status]++
+}
+
+var publishExpvarsOnce sync.Once
+
+// PublishExpvars exposes the metrics gathered by PerformanceMonitor through
+// the standard expvar package as the "routix" variable: total and active
+// requests, errors, and per-status counts. expvar serves them at /debug/vars
+// on http.DefaultServeMux; to serve them from a Router, mount
+// expvar.Handler(). Calling it more than once is harmless.
+func PublishExpvars() {
+	publishExpvarsOnce.Do(func() {
+		expvar.Publish("routix", expvar.Func(func() interface{} {
+			globalMetrics.mu.RLock()
+			defer globalMetrics.mu.RUnlock()
+
+			statuses := make(map[string]int64, len(globalMetrics.StatusCounts))
+			for status, n := range globalMetrics.StatusCounts {
+				statuses[strconv.Itoa(status)] = n
+			}
+			return map[string]interface{}{
+				"total_requests":  globalMetrics.RequestCount,
+				"active_requests": globalMetrics.ActiveRequests,
+				"error_count":     globalMetrics.ErrorCount,
+				"status_codes":    statuses,
+			}
+		}))
+	})
+}
+
 // Performance monitoring middleware
 func PerformanceMonitor() Middleware {
 	return func(next Handler) Handler {
@@ -139,6 +168,7 @@ func PerformanceMonitor() Middleware {
 			// Calculate latency and update metrics
 			latency := time.Since(start)
 			globalMetrics.UpdateMetrics(latency, err != nil)
+			globalMetrics.recordStatus(responseStatus(c, err))
 			
 			// Decrement active requests
 			globalMetrics.mu.Lock()
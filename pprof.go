@@ -0,0 +1,36 @@
+package routix
+
+import (
+	"net/http/pprof"
+	"strings"
+)
+
+// MountPprof registers the net/http/pprof handlers under prefix, e.g.
+// /debug/pprof. Nothing is exposed unless this is called; guard the prefix
+// with auth middleware in production.
+func (r *Router) MountPprof(prefix string) *Router {
+	prefix = "/" + strings.Trim(prefix, "/")
+
+	handler := func(c *Context) error {
+		switch name := c.Params["*"]; name {
+		case "":
+			pprof.Index(c.Response, c.Request)
+		case "cmdline":
+			pprof.Cmdline(c.Response, c.Request)
+		case "profile":
+			pprof.Profile(c.Response, c.Request)
+		case "symbol":
+			pprof.Symbol(c.Response, c.Request)
+		case "trace":
+			pprof.Trace(c.Response, c.Request)
+		default:
+			pprof.Handler(name).ServeHTTP(c.Response, c.Request)
+		}
+		return nil
+	}
+
+	r.GET(prefix, handler)
+	r.GET(prefix+"/*", handler)
+	r.POST(prefix+"/*", handler)
+	return r
+}
@@ -1,9 +1,15 @@
 package routix
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -73,6 +79,42 @@ func Respond[T any](status ResponseStatus, data T) (interface{}, error) {
 	}, nil
 }
 
+// Layouts for Router.TimestampFormat that aren't time layouts.
+const (
+	TimestampUnix      = "unix"
+	TimestampUnixMilli = "unixmilli"
+)
+
+// TimestampFormat sets how the timestamp of response envelopes is written:
+// a time layout such as time.RFC3339Nano, TimestampUnix or
+// TimestampUnixMilli. Timestamps are always in UTC. The default is
+// time.RFC3339.
+func (r *Router) TimestampFormat(layout string) *Router {
+	r.timeFormat = layout
+	return r
+}
+
+func formatTimestamp(t time.Time, layout string) string {
+	switch layout {
+	case "":
+		return t.UTC().Format(time.RFC3339)
+	case TimestampUnix:
+		return strconv.FormatInt(t.Unix(), 10)
+	case TimestampUnixMilli:
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	default:
+		return t.UTC().Format(layout)
+	}
+}
+
+// timestamp returns the current time in the router's envelope format.
+func (c *Context) timestamp() string {
+	if c.router == nil {
+		return formatTimestamp(time.Now(), "")
+	}
+	return formatTimestamp(time.Now(), c.router.timeFormat)
+}
+
 func RespondPaginated[T any](data T, pageNumber, totalPages int) SuccessResponse[struct {
 	Page       T   `json:"page"`
 	PageNumber int `json:"pageNumber"`
@@ -117,12 +159,67 @@ func ConvertError(err error, fallbackMessage string) error {
 	}
 }
 
+// JSON writes data as a JSON response with the given status. data is
+// encoded before anything is written, so when encoding fails nothing reaches
+// the client and the error is returned for the router to answer with 500.
 func (c *Context) JSON(status int, data interface{}) error {
+	return c.writeJSON(status, "", data)
+}
+
+// writeJSON encodes data, then writes the status, prefix and encoded body.
+func (c *Context) writeJSON(status int, prefix string, data interface{}) error {
+	var buf bytes.Buffer
+	buf.WriteString(prefix)
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(data); err != nil {
+		return err
+	}
 	c.Response.Header().Set("Content-Type", "application/json; charset=utf-8")
 	c.Response.WriteHeader(status)
-	encoder := json.NewEncoder(c.Response)
-	encoder.SetEscapeHTML(false)
-	return encoder.Encode(data)
+	_, err := c.Response.Write(buf.Bytes())
+	return err
+}
+
+// DefaultSecureJSONPrefix is what SecureJSON writes before the JSON unless
+// Router.SecureJSONPrefix sets another guard.
+const DefaultSecureJSONPrefix = ")]}',\n"
+
+// SecureJSON writes data like JSON, preceded by a guard that stops the body
+// being run as a script, which protects top-level arrays from legacy JSON
+// hijacking. Clients strip the guard before parsing.
+func (c *Context) SecureJSON(status int, data interface{}) error {
+	prefix := DefaultSecureJSONPrefix
+	if c.router != nil && c.router.jsonPrefix != "" {
+		prefix = c.router.jsonPrefix
+	}
+	return c.writeJSON(status, prefix, data)
+}
+
+// SecureJSONPrefix sets the guard SecureJSON writes before the JSON,
+// DefaultSecureJSONPrefix by default.
+func (r *Router) SecureJSONPrefix(prefix string) *Router {
+	r.jsonPrefix = prefix
+	return r
+}
+
+// ProtoMarshaler is implemented by protobuf messages that can encode
+// themselves. It keeps protobuf out of routix's dependencies; wrap
+// proto.Marshal in a small adapter for google.golang.org/protobuf types.
+type ProtoMarshaler interface {
+	Marshal() ([]byte, error)
+}
+
+// Protobuf writes msg as an application/x-protobuf response.
+func (c *Context) Protobuf(status int, msg ProtoMarshaler) error {
+	data, err := msg.Marshal()
+	if err != nil {
+		return err
+	}
+	c.Response.Header().Set("Content-Type", "application/x-protobuf")
+	c.Response.WriteHeader(status)
+	_, err = c.Response.Write(data)
+	return err
 }
 
 func (c *Context) FastJSON(status int, data interface{}) error {
@@ -149,57 +246,196 @@ func (c *Context) FastJSON(status int, data interface{}) error {
 }
 
 func (c *Context) Success(data interface{}) error {
-	response, err := Respond(StatusSuccess, data)
-	if err != nil {
-		return err
-	}
-	return c.JSON(200, response)
+	return c.successWithStatus(http.StatusOK, data)
 }
 
 func (c *Context) Error(err error, fallbackMessage string) error {
-	convertedErr := ConvertError(err, fallbackMessage)
-	return c.JSON(400, convertedErr)
+	convertedErr := *ConvertError(err, fallbackMessage).(*RespondError)
+	convertedErr.Timestamp = c.timestamp()
+	return c.envelope(400, &convertedErr, "data")
 }
 
 func (c *Context) Paginated(data interface{}, pageNumber, totalPages int) error {
 	response := RespondPaginated(data, pageNumber, totalPages)
-	return c.JSON(200, response)
+	response.Timestamp = c.timestamp()
+	return c.envelope(200, response, "data")
 }
 
+// SetPaginationHeaders emits X-Total-Count and an RFC 5988 Link header with
+// first/prev/next/last URLs derived from the current request URL. Page
+// numbers are carried in the page and per_page query parameters.
+func (c *Context) SetPaginationHeaders(page, perPage, total int) {
+	if perPage < 1 {
+		perPage = 1
+	}
+	lastPage := (total + perPage - 1) / perPage
+	if lastPage < 1 {
+		lastPage = 1
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	c.Response.Header().Set("X-Total-Count", strconv.Itoa(total))
+
+	links := []string{c.pageLink(1, perPage, "first")}
+	if page > 1 {
+		links = append(links, c.pageLink(page-1, perPage, "prev"))
+	}
+	if page < lastPage {
+		links = append(links, c.pageLink(page+1, perPage, "next"))
+	}
+	links = append(links, c.pageLink(lastPage, perPage, "last"))
+
+	c.Response.Header().Set("Link", strings.Join(links, ", "))
+}
+
+func (c *Context) pageLink(page, perPage int, rel string) string {
+	u := *c.Request.URL
+	u.Host = c.Request.Host
+	u.Scheme = "http"
+	if c.Request.TLS != nil {
+		u.Scheme = "https"
+	}
+	if proto := c.Request.Header.Get("X-Forwarded-Proto"); proto != "" {
+		u.Scheme = proto
+	}
+
+	q := u.Query()
+	q.Set("page", strconv.Itoa(page))
+	q.Set("per_page", strconv.Itoa(perPage))
+	u.RawQuery = q.Encode()
+
+	return fmt.Sprintf(`<%s>; rel="%s"`, u.String(), rel)
+}
+
+// Created writes data in the standard success envelope with 201 Created.
 func (c *Context) Created(data interface{}) error {
-	return c.JSON(http.StatusCreated, map[string]any{"status": "success", "data": data})
+	return c.successWithStatus(http.StatusCreated, data)
 }
 
+// Accepted writes data in the standard success envelope with 202 Accepted.
 func (c *Context) Accepted(data interface{}) error {
-	return c.JSON(http.StatusAccepted, map[string]any{"status": "success", "data": data})
+	return c.successWithStatus(http.StatusAccepted, data)
+}
+
+// Raw makes Success, Created and Accepted write their data as the bare
+// response body, without the status/timestamp/data envelope, for the rest
+// of the request. Use it for endpoints such as webhook receivers whose
+// callers expect a specific body.
+func (c *Context) Raw() *Context {
+	c.raw = true
+	return c
+}
+
+func (c *Context) successWithStatus(status int, data interface{}) error {
+	if c.raw {
+		return c.JSON(status, data)
+	}
+	response, err := Respond(StatusSuccess, data)
+	if err != nil {
+		return err
+	}
+	if r, ok := response.(SuccessResponse[interface{}]); ok {
+		r.Timestamp = c.timestamp()
+		response = r
+	}
+	return c.envelope(status, response)
+}
+
+// StreamAttachment streams r to the client as a download named filename
+// without buffering it. size becomes the Content-Length; pass -1 when it
+// is unknown. A reader shorter than size results in io.ErrUnexpectedEOF.
+func (c *Context) StreamAttachment(filename string, size int64, r io.Reader) error {
+	h := c.Response.Header()
+	h.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	contentType := mime.TypeByExtension(filepath.Ext(filename))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	h.Set("Content-Type", contentType)
+	if size >= 0 {
+		h.Set("Content-Length", strconv.FormatInt(size, 10))
+		r = io.LimitReader(r, size)
+	}
+	c.Response.WriteHeader(http.StatusOK)
+
+	n, err := io.Copy(c.Response, r)
+	if err != nil {
+		return err
+	}
+	if size >= 0 && n < size {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+// ItemResult is the outcome of one item in a batch request.
+type ItemResult struct {
+	Index  int         `json:"index"`
+	Status int         `json:"status"`
+	Data   interface{} `json:"data,omitempty"`
+	Error  string      `json:"error,omitempty"`
 }
 
+// ItemOK reports a successfully processed batch item.
+func ItemOK(index, status int, data interface{}) ItemResult {
+	return ItemResult{Index: index, Status: status, Data: data}
+}
+
+// ItemFailed reports a failed batch item, taking its status from err.
+func ItemFailed(index int, err error) ItemResult {
+	message := err.Error()
+	if e, ok := err.(*Error); ok {
+		message = e.Message
+	}
+	return ItemResult{Index: index, Status: GetHTTPStatusCode(err), Error: message}
+}
+
+// MultiStatus writes a 207 Multi-Status response carrying one entry per
+// batch item, so clients can tell which items succeeded.
+func (c *Context) MultiStatus(results []ItemResult) error {
+	if results == nil {
+		results = []ItemResult{}
+	}
+	return c.envelope(http.StatusMultiStatus, map[string]any{
+		"status":    "multi_status",
+		"timestamp": c.timestamp(),
+		"results":   results,
+	})
+}
+
+// NoContent writes 204 No Content with an empty body.
 func (c *Context) NoContent() error {
 	c.Response.WriteHeader(http.StatusNoContent)
 	return nil
 }
 
 func (c *Context) BadRequest(message string) error {
-	return c.JSON(http.StatusBadRequest, map[string]any{"status": "error", "message": message})
+	return c.envelope(http.StatusBadRequest, map[string]any{"status": "error", "message": message})
 }
 
 func (c *Context) Unauthorized(message string) error {
 	if message == "" {
 		message = "unauthorized"
 	}
-	return c.JSON(http.StatusUnauthorized, map[string]any{"status": "error", "message": message})
+	return c.envelope(http.StatusUnauthorized, map[string]any{"status": "error", "message": message})
 }
 
 func (c *Context) Forbidden(message string) error {
 	if message == "" {
 		message = "forbidden"
 	}
-	return c.JSON(http.StatusForbidden, map[string]any{"status": "error", "message": message})
+	return c.envelope(http.StatusForbidden, map[string]any{"status": "error", "message": message})
 }
 
 func (c *Context) NotFound(message string) error {
 	if message == "" {
 		message = "not found"
 	}
-	return c.JSON(http.StatusNotFound, map[string]any{"status": "error", "message": message})
+	return c.envelope(http.StatusNotFound, map[string]any{"status": "error", "message": message})
+}
+
+func (c *Context) PreconditionFailed() error {
+	return c.envelope(http.StatusPreconditionFailed, map[string]any{"status": "error", "message": "precondition failed"})
 }
@@ -0,0 +1,127 @@
+package routix
+
+import "time"
+
+// Route is the handle returned when registering a handler. It attaches
+// per-route behaviour and documentation after the fact:
+//
+//	r.POST("/users", createUser).
+//		Request(userSchema).
+//		Response(201, userSchema)
+type Route struct {
+	router  *Router
+	index   int
+	node    *node
+	handler Handler    // the handler with route middleware, without group middleware
+	group   Middleware // the group's middleware chain, or nil
+}
+
+// Info returns the route's current registration details.
+func (rt *Route) Info() RouteInfo {
+	rt.router.mu.RLock()
+	defer rt.router.mu.RUnlock()
+	return rt.router.routes[rt.index]
+}
+
+// Request validates JSON request bodies against schema before the handler
+// runs, rejecting invalid ones with 400, and documents the schema as the
+// request body in the OpenAPI spec.
+func (rt *Route) Request(schema Schema) *Route {
+	rt.router.mu.Lock()
+	rt.router.routes[rt.index].Request = schema
+	rt.router.mu.Unlock()
+
+	rt.wrap(func(next Handler) Handler {
+		return func(c *Context) error {
+			var body any
+			if m := c.BodyMap(); m != nil {
+				body = m
+			}
+			if err := schema.Validate(body); err != nil {
+				return BadRequest("Validation failed", ValidationErrors{NewValidationError("body", err.Error())})
+			}
+			return next(c)
+		}
+	})
+	return rt
+}
+
+// Response documents the schema of responses with the given status in the
+// OpenAPI spec. Responses are not validated at runtime.
+func (rt *Route) Response(status int, schema Schema) *Route {
+	rt.router.mu.Lock()
+	defer rt.router.mu.Unlock()
+	info := &rt.router.routes[rt.index]
+	if info.Responses == nil {
+		info.Responses = make(map[int]Schema)
+	}
+	info.Responses[status] = schema
+	return rt
+}
+
+// Raw makes the route's success responses bare, as if the handler called
+// c.Raw() first.
+func (rt *Route) Raw() *Route {
+	rt.wrap(func(next Handler) Handler {
+		return func(c *Context) error {
+			c.Raw()
+			return next(c)
+		}
+	})
+	return rt
+}
+
+// Cache serves repeated GET requests for the route from a copy of the first
+// response, keyed by path and query, until ttl passes. Errors and 5xx responses
+// are not stored.
+//
+//	r.GET("/about", about).Cache(24 * time.Hour)
+func (rt *Route) Cache(ttl time.Duration) *Route {
+	rt.wrap(Cache(ttl))
+	return rt
+}
+
+// RateLimit limits each client IP to n requests to this route per window,
+// independently of any router or group limit:
+//
+//	r.POST("/login", login).RateLimit(5, time.Minute)
+func (rt *Route) RateLimit(n int, window time.Duration) *Route {
+	rt.wrap(RateLimit(n, window))
+	return rt
+}
+
+// UseNamed wraps the route's handler in mw, reported as name in route
+// listings. Like Cache and RateLimit it runs outside middleware the route
+// already has.
+func (rt *Route) UseNamed(name string, mw Middleware) *Route {
+	rt.wrapNamed(name, mw)
+	return rt
+}
+
+// with wraps the handler in per-route middleware, the first listed
+// outermost.
+func (rt *Route) with(middleware []Middleware) *Route {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		rt.wrap(middleware[i])
+	}
+	return rt
+}
+
+// wrap applies mw to the route's handler only. It runs inside any group
+// middleware the route was registered with, and outside middleware wrapped
+// before it.
+func (rt *Route) wrap(mw Middleware) {
+	rt.wrapNamed(MiddlewareName(mw), mw)
+}
+
+func (rt *Route) wrapNamed(name string, mw Middleware) {
+	rt.router.mu.Lock()
+	defer rt.router.mu.Unlock()
+	rt.handler = mw(abortable(rt.handler))
+	rt.node.handler = rt.handler
+	if rt.group != nil {
+		rt.node.handler = rt.group(rt.handler)
+	}
+	info := &rt.router.routes[rt.index]
+	info.routeMiddleware = append([]string{name}, info.routeMiddleware...)
+}
@@ -3,10 +3,14 @@ package routix
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"reflect"
+	"sort"
 	"strings"
 	"sync"
 	"syscall"
@@ -17,6 +21,7 @@ import (
 type responseWriter struct {
 	http.ResponseWriter
 	status  int
+	size    int
 	written bool
 }
 
@@ -33,7 +38,9 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 		rw.status = http.StatusOK
 		rw.written = true
 	}
-	return rw.ResponseWriter.Write(b)
+	n, err := rw.ResponseWriter.Write(b)
+	rw.size += n
+	return n, err
 }
 
 func (rw *responseWriter) Status() int {
@@ -43,6 +50,27 @@ func (rw *responseWriter) Status() int {
 	return rw.status
 }
 
+// Size returns the number of body bytes written so far.
+func (rw *responseWriter) Size() int {
+	return rw.size
+}
+
+// Flush sends buffered data to the client, so streaming responses such as
+// server-sent events reach it as they are written.
+func (rw *responseWriter) Flush() {
+	if !rw.written {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // Context holds request/response state for a single HTTP request.
 type Context struct {
 	Request  *http.Request
@@ -52,6 +80,11 @@ type Context struct {
 	Query    map[string]string
 	Body     map[string]any
 	values   map[string]any
+	bodyBuf  []byte
+	form     url.Values
+	router   *Router
+	raw      bool
+	aborted  bool
 }
 
 // Set stores a value in the context, scoped to this request.
@@ -71,6 +104,28 @@ func (c *Context) Get(key string) (any, bool) {
 	return v, ok
 }
 
+// onceResult is what Once keeps in the value store.
+type onceResult struct {
+	value any
+	err   error
+}
+
+// Once runs fn the first time it is called with key during a request and
+// returns that result, error included, on later calls, so middleware and
+// handlers can share an expensive computation such as decoding a token.
+// Results live in the request's value store under "routix.once:"+key.
+// Once is not safe for concurrent use within a request.
+func (c *Context) Once(key string, fn func() (any, error)) (any, error) {
+	key = "routix.once:" + key
+	if v, ok := c.Get(key); ok {
+		r := v.(onceResult)
+		return r.value, r.err
+	}
+	value, err := fn()
+	c.Set(key, onceResult{value, err})
+	return value, err
+}
+
 // MustGet retrieves a value or panics if the key doesn't exist.
 func (c *Context) MustGet(key string) any {
 	v, ok := c.Get(key)
@@ -80,41 +135,73 @@ func (c *Context) MustGet(key string) any {
 	return v
 }
 
-// Status returns the HTTP status code written for this request.
+// GetString returns the value stored under key as a string, or "" when it
+// is missing or not a string.
+func (c *Context) GetString(key string) string {
+	s, _ := c.values[key].(string)
+	return s
+}
+
+// GetInt returns the value stored under key as an int, or 0 when it is
+// missing or not an int.
+func (c *Context) GetInt(key string) int {
+	n, _ := c.values[key].(int)
+	return n
+}
+
+// Status returns the HTTP status code written for this request. ServeHTTP
+// wraps every response once, so middleware can read it after calling the
+// next handler without wrapping the writer themselves.
 func (c *Context) Status() int {
 	return c.Writer.Status()
 }
 
+// StatusCode is Status under the name other frameworks use.
+func (c *Context) StatusCode() int {
+	return c.Status()
+}
+
 func getContextFromPool(req *http.Request, w *responseWriter, params, query map[string]string, body map[string]any) *Context {
 	ctx := getContext()
+	if DevMode {
+		assertContextReset(ctx)
+	}
 	ctx.Request = req
 	ctx.Writer = w
 	ctx.Response = w
 	ctx.Params = params
 	ctx.Query = query
 	ctx.Body = body
-	ctx.values = nil
 	return ctx
 }
 
 func putContextToPool(ctx *Context) {
-	ctx.Request = nil
-	ctx.Writer = nil
-	ctx.Response = nil
-	ctx.Params = nil
-	ctx.Query = nil
-	ctx.Body = nil
-	ctx.values = nil
 	putContext(ctx)
 }
 
+// assertContextReset panics when a pooled Context still holds state from
+// an earlier request. It guards putContext in dev mode.
+func assertContextReset(ctx *Context) {
+	v := reflect.ValueOf(ctx).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if !v.Field(i).IsZero() {
+			panic(fmt.Sprintf("routix: pooled Context field %s was not reset", v.Type().Field(i).Name))
+		}
+	}
+}
+
 // Handler is a function that handles an HTTP request.
 type Handler func(*Context) error
 
 // RouteInfo holds information about a registered route.
 type RouteInfo struct {
-	Method string
-	Path   string
+	Method     string
+	Path       string
+	Middleware []string       // global, group, then route middleware names, outermost first
+	Request    Schema         // request body schema, set with Route.Request
+	Responses  map[int]Schema // response schemas by status, set with Route.Response
+
+	routeMiddleware []string // added through the Route handle, outermost first
 }
 
 // Router is the core HTTP router.
@@ -124,9 +211,31 @@ type Router struct {
 	params     *sync.Pool
 	notFound   Handler
 	notMethod  Handler
-	middleware []Middleware
+	middleware []namedMiddleware
 	cache      sync.Map
 	devMode    bool
+	decoders   map[string]Decoder
+	naming     NamingStyle
+	platform   string
+	jsonDepth  int
+	maxParams  int
+	timeFormat string
+	fallback   Handler
+	groupMiss  []groupNotFound
+	listeners  map[string][]func(payload interface{})
+	reloaders  []func()
+	quiet      bool
+	autoParse  bool
+	onPanic    func(c *Context, v interface{})
+	noRecover  bool
+	version    string
+	buildTime  string
+	vary       []string
+	staticDirs []string
+	after      []func(c *Context)
+	envKeys    map[string]string
+	jsonPrefix string
+	strict     bool
 	mu         sync.RWMutex
 }
 
@@ -136,27 +245,35 @@ type node struct {
 	children map[string]*node
 	params   []string
 	wildcard bool
+	route    int // index in Router.routes of the route owning handler
 }
 
 // Middleware wraps a Handler with additional logic.
 type Middleware func(Handler) Handler
 
-// New creates a new Router with sensible defaults.
+// AppEnvVar names the environment variable that selects the app
+// environment. New turns dev mode on when it is set to "development".
+const AppEnvVar = "APP_ENV"
+
+// New creates a Router with no middleware. Dev mode is off unless APP_ENV
+// is "development", so in production it writes nothing to stdout on its
+// own. Quick adds Logger, Recovery and CORS.
 func New() *Router {
 	return &Router{
-		trees: make(map[string]*node),
-		params: &sync.Pool{
-			New: func() any {
-				return make(map[string]string)
-			},
-		},
+		trees:     make(map[string]*node),
+		params:    newParamsPool(DefaultMaxParams),
+		maxParams: DefaultMaxParams,
 		notFound: func(c *Context) error {
-			c.Response.Header().Set("Content-Type", "application/json")
-			c.Response.WriteHeader(http.StatusNotFound)
-			json.NewEncoder(c.Response).Encode(map[string]any{
+			body := map[string]any{
 				"status":  "error",
 				"message": "route not found",
-			})
+			}
+			if suggestion, ok := c.Get(suggestionKey); ok {
+				body["suggestion"] = suggestion
+			}
+			c.Response.Header().Set("Content-Type", "application/json")
+			c.Response.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(c.Response).Encode(body)
 			return nil
 		},
 		notMethod: func(c *Context) error {
@@ -168,39 +285,226 @@ func New() *Router {
 			})
 			return nil
 		},
-		devMode: false,
+		devMode: os.Getenv(AppEnvVar) == "development",
 	}
 }
 
 // Use appends global middleware to the router.
 func (r *Router) Use(middleware ...Middleware) *Router {
-	r.middleware = append(r.middleware, middleware...)
+	r.middleware = append(r.middleware, nameMiddleware(middleware)...)
 	return r
 }
 
-// EnableDevMode turns on verbose request logging.
+// UseNamed appends global middleware that Routes and route listings report
+// as name rather than by the function that built it.
+func (r *Router) UseNamed(name string, mw Middleware) *Router {
+	r.middleware = append(r.middleware, namedMiddleware{name, mw})
+	return r
+}
+
+// EnableDevMode turns on verbose request logging, such as printing
+// unmatched routes, regardless of APP_ENV.
 func (r *Router) EnableDevMode() *Router {
 	r.devMode = true
 	return r
 }
 
+// TrustedPlatform makes ClientIP read the client address from the header
+// set by the given platform (PlatformCloudflare, PlatformGCP, PlatformAWS,
+// PlatformFly, or a header name) instead of parsing X-Forwarded-For. Only
+// enable it when the app is reachable exclusively through that platform.
+func (r *Router) TrustedPlatform(platform string) *Router {
+	r.platform = platform
+	return r
+}
+
+// MaxJSONDepth limits how deeply JSON request bodies may nest objects and
+// arrays; deeper bodies are rejected by Bind and ParseJSON with a 400.
+// The default is DefaultMaxJSONDepth.
+func (r *Router) MaxJSONDepth(depth int) *Router {
+	r.jsonDepth = depth
+	return r
+}
+
+// DefaultMaxParams is the default limit on captured path parameters.
+const DefaultMaxParams = 16
+
+// MaxParams limits how many named parameters a request may capture and
+// sizes the pooled parameter maps accordingly. Parameters past the limit
+// are ignored, which bounds the work done for deeply parameterized paths.
+// The default is DefaultMaxParams.
+func (r *Router) MaxParams(n int) *Router {
+	if n < 1 {
+		n = DefaultMaxParams
+	}
+	r.maxParams = n
+	r.params = newParamsPool(n)
+	return r
+}
+
+func newParamsPool(size int) *sync.Pool {
+	return &sync.Pool{
+		New: func() any {
+			return make(map[string]string, size)
+		},
+	}
+}
+
 // Routes returns a snapshot of all registered routes.
 func (r *Router) Routes() []RouteInfo {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
+	global := middlewareNames(r.middleware)
 	out := make([]RouteInfo, len(r.routes))
-	copy(out, r.routes)
+	for i, route := range r.routes {
+		out[i] = route
+		out[i].Middleware = append(append(append([]string{}, global...), route.Middleware...), route.routeMiddleware...)
+		out[i].routeMiddleware = nil
+	}
 	return out
 }
 
+// AllowedMethods returns the methods, sorted, that have a route matching
+// the request path. OPTIONS requests to such a path are answered
+// automatically with 204 and an Allow header listing them, after passing
+// through global middleware such as CORS, unless an OPTIONS route matches.
+func (r *Router) AllowedMethods(path string) []string {
+	var methods []string
+	for method, root := range r.trees {
+		if method == http.MethodOptions {
+			continue
+		}
+		if r.findNode(root, path, make(map[string]string)) != nil {
+			methods = append(methods, method)
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// optionsHandler answers an OPTIONS request for a path served by allowed.
+func optionsHandler(allowed []string) Handler {
+	return func(c *Context) error {
+		c.SetHeader("Allow", strings.Join(append(allowed, http.MethodOptions), ", "))
+		c.Response.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
+
+// EffectiveMiddleware returns the names of the middleware that would run,
+// outermost first, for a method and request path: global, then group, then
+// route middleware. It returns nil when no route matches. Give anonymous
+// middleware a name with UseNamed so the list is readable.
+func (r *Router) EffectiveMiddleware(method, path string) []string {
+	root, ok := r.trees[method]
+	if !ok {
+		return nil
+	}
+	n := r.findNode(root, path, make(map[string]string))
+	if n == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	route := r.routes[n.route]
+	return append(append(middlewareNames(r.middleware), route.Middleware...), route.routeMiddleware...)
+}
+
+// Validate checks the router for common misconfigurations: two routes with
+// the same method and pattern (parameter names aside), path segments after
+// a * wildcard, which can never match, nil global middleware, and Static
+// directories that don't exist. It reports every problem found, joined.
+func (r *Router) Validate() error {
+	var errs []error
+	for i, m := range r.middleware {
+		if m.mw == nil {
+			errs = append(errs, fmt.Errorf("global middleware %d is nil", i))
+		}
+	}
+
+	seen := make(map[string]string)
+	for _, route := range r.Routes() {
+		key := route.Method + " " + routeShape(route.Path)
+		if prev, ok := seen[key]; ok {
+			errs = append(errs, fmt.Errorf("duplicate route %s %s (conflicts with %s)", route.Method, route.Path, prev))
+		} else {
+			seen[key] = route.Path
+		}
+		if strings.Contains(route.Path, "/*/") {
+			errs = append(errs, fmt.Errorf("route %s %s: segments after * are unreachable", route.Method, route.Path))
+		}
+	}
+
+	r.mu.RLock()
+	dirs := append([]string{}, r.staticDirs...)
+	r.mu.RUnlock()
+	for _, dir := range dirs {
+		if info, err := os.Stat(dir); err != nil {
+			errs = append(errs, fmt.Errorf("static directory %s: %w", dir, err))
+		} else if !info.IsDir() {
+			errs = append(errs, fmt.Errorf("static directory %s is not a directory", dir))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// routeShape reduces a pattern to the tree path it occupies: parameter
+// names don't matter and empty segments are dropped.
+func routeShape(path string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(path, "/") {
+		if part == "" {
+			continue
+		}
+		if part[0] == ':' {
+			part = ":"
+		}
+		b.WriteString("/" + part)
+	}
+	if b.Len() == 0 {
+		return "/"
+	}
+	return b.String()
+}
+
+// ValidateOnStart makes Start refuse to serve when Validate reports a
+// problem.
+func (r *Router) ValidateOnStart() *Router {
+	r.strict = true
+	return r
+}
+
+// checkStart runs Validate when ValidateOnStart is set.
+func (r *Router) checkStart() error {
+	if !r.strict {
+		return nil
+	}
+	if err := r.Validate(); err != nil {
+		return fmt.Errorf("routix: invalid router configuration: %w", err)
+	}
+	return nil
+}
+
 // Handle registers a handler for the given method and path.
-func (r *Router) Handle(method, path string, handler Handler) {
+//
+// Middleware passed after the handler applies to this route only, inside
+// any global middleware; the first listed runs first:
+//
+//	r.GET("/admin", dashboard, AdminOnly(), Audit())
+func (r *Router) Handle(method, path string, handler Handler, middleware ...Middleware) *Route {
+	return r.addRoute(method, path, handler, nil).with(middleware)
+}
+
+// addRoute registers handler, recording the names of the middleware already
+// wrapped around it for introspection.
+func (r *Router) addRoute(method, path string, handler Handler, middleware []string) *Route {
 	if len(path) == 0 || path[0] != '/' {
 		path = "/" + path
 	}
 
 	r.mu.Lock()
-	r.routes = append(r.routes, RouteInfo{Method: method, Path: path})
+	r.routes = append(r.routes, RouteInfo{Method: method, Path: path, Middleware: middleware})
+	route := &Route{router: r, index: len(r.routes) - 1, handler: handler}
 	r.mu.Unlock()
 
 	if _, ok := r.trees[method]; !ok {
@@ -211,7 +515,9 @@ func (r *Router) Handle(method, path string, handler Handler) {
 
 	if path == "/" {
 		root.handler = handler
-		return
+		root.route = route.index
+		route.node = root
+		return route
 	}
 
 	parts := strings.Split(path[1:], "/")
@@ -254,19 +560,56 @@ func (r *Router) Handle(method, path string, handler Handler) {
 			root.handler = handler
 		}
 	}
+	root.route = route.index
+	route.node = root
+	return route
 }
 
-func (r *Router) GET(path string, handler Handler)     { r.Handle(http.MethodGet, path, handler) }
-func (r *Router) POST(path string, handler Handler)    { r.Handle(http.MethodPost, path, handler) }
-func (r *Router) PUT(path string, handler Handler)     { r.Handle(http.MethodPut, path, handler) }
-func (r *Router) DELETE(path string, handler Handler)  { r.Handle(http.MethodDelete, path, handler) }
-func (r *Router) PATCH(path string, handler Handler)   { r.Handle(http.MethodPatch, path, handler) }
-func (r *Router) HEAD(path string, handler Handler)    { r.Handle(http.MethodHead, path, handler) }
-func (r *Router) OPTIONS(path string, handler Handler) { r.Handle(http.MethodOptions, path, handler) }
+func (r *Router) GET(path string, handler Handler, middleware ...Middleware) *Route {
+	return r.Handle(http.MethodGet, path, handler, middleware...)
+}
+
+func (r *Router) POST(path string, handler Handler, middleware ...Middleware) *Route {
+	return r.Handle(http.MethodPost, path, handler, middleware...)
+}
+
+func (r *Router) PUT(path string, handler Handler, middleware ...Middleware) *Route {
+	return r.Handle(http.MethodPut, path, handler, middleware...)
+}
+
+func (r *Router) DELETE(path string, handler Handler, middleware ...Middleware) *Route {
+	return r.Handle(http.MethodDelete, path, handler, middleware...)
+}
+
+func (r *Router) PATCH(path string, handler Handler, middleware ...Middleware) *Route {
+	return r.Handle(http.MethodPatch, path, handler, middleware...)
+}
+
+func (r *Router) HEAD(path string, handler Handler, middleware ...Middleware) *Route {
+	return r.Handle(http.MethodHead, path, handler, middleware...)
+}
+
+func (r *Router) OPTIONS(path string, handler Handler, middleware ...Middleware) *Route {
+	return r.Handle(http.MethodOptions, path, handler, middleware...)
+}
 
 func (r *Router) NotFound(handler Handler)        { r.notFound = handler }
 func (r *Router) MethodNotAllowed(handler Handler) { r.notMethod = handler }
 
+// Fallback handles requests no route matches, instead of the 404 handler.
+// Unlike NotFound it runs behind the global middleware like a route, which
+// suits serving an SPA shell next to an API:
+//
+//	r.GET("/api/users", listUsers)
+//	r.Fallback(func(c *routix.Context) error {
+//		http.ServeFile(c.Response, c.Request, "dist/index.html")
+//		return nil
+//	})
+func (r *Router) Fallback(handler Handler) *Router {
+	r.fallback = handler
+	return r
+}
+
 func (r *Router) CacheResponse(key string, response []byte, headers http.Header, code int, duration time.Duration) {
 	r.cache.Store(key, struct {
 		response []byte
@@ -292,13 +635,65 @@ func (r *Router) GetCachedResponse(key string) ([]byte, http.Header, int, bool)
 	return nil, nil, 0, false
 }
 
+// CacheVary sets the request headers whose values tell apart variants of a
+// response cached with CacheResponseFor. The default is Accept-Encoding,
+// so gzip and plain clients never receive each other's body.
+func (r *Router) CacheVary(headers ...string) *Router {
+	r.vary = append([]string{}, headers...)
+	return r
+}
+
+// CacheResponseFor caches a response for req's path like CacheResponse,
+// keeping a separate entry for each variant of the CacheVary headers.
+func (r *Router) CacheResponseFor(req *http.Request, response []byte, headers http.Header, code int, duration time.Duration) {
+	r.CacheResponse(r.variantKey(req), response, headers, code, duration)
+}
+
+func (r *Router) variantKey(req *http.Request) string {
+	vary := r.vary
+	if vary == nil {
+		vary = []string{"Accept-Encoding"}
+	}
+
+	var b strings.Builder
+	b.WriteString(req.URL.Path)
+	for _, name := range vary {
+		value := req.Header.Get(name)
+		if http.CanonicalHeaderKey(name) == "Accept-Encoding" {
+			// Key by the encoding the client gets, not the raw header.
+			value = negotiateEncoding(value, brotliEncoder != nil)
+		}
+		b.WriteByte(0)
+		b.WriteString(value)
+	}
+	return b.String()
+}
+
+// cachedResponse finds a cached response for req: the entry for its
+// variant, else one cached by path alone, provided the client accepts the
+// entry's Content-Encoding.
+func (r *Router) cachedResponse(req *http.Request) ([]byte, http.Header, int, bool) {
+	if response, headers, code, ok := r.GetCachedResponse(r.variantKey(req)); ok {
+		return response, headers, code, true
+	}
+	response, headers, code, ok := r.GetCachedResponse(req.URL.Path)
+	if !ok {
+		return nil, nil, 0, false
+	}
+	if enc := headers.Get("Content-Encoding"); enc != "" &&
+		negotiateEncoding(req.Header.Get("Accept-Encoding"), enc == "br") != enc {
+		return nil, nil, 0, false
+	}
+	return response, headers, code, true
+}
+
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	path := req.URL.Path
 	method := req.Method
 
 	// Serve cached GET responses without hitting the handler chain.
 	if method == http.MethodGet {
-		if response, headers, code, ok := r.GetCachedResponse(path); ok {
+		if response, headers, code, ok := r.cachedResponse(req); ok {
 			for k, v := range headers {
 				w.Header()[k] = v
 			}
@@ -311,7 +706,7 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	rw := &responseWriter{ResponseWriter: w}
 
 	root, ok := r.trees[method]
-	if !ok {
+	if !ok && r.fallback == nil && (method != http.MethodOptions || len(r.AllowedMethods(path)) == 0) {
 		r.notMethod(getContextFromPool(req, rw, nil, nil, nil))
 		return
 	}
@@ -334,45 +729,89 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		}
 	}
 
-	// Parse JSON body when content-type is application/json.
-	// ContentLength == -1 means chunked; still attempt decode.
-	var body map[string]any
-	ct := req.Header.Get("Content-Type")
-	if strings.HasPrefix(ct, "application/json") && req.Body != nil {
-		json.NewDecoder(req.Body).Decode(&body) //nolint:errcheck
+	ctx := getContextFromPool(req, rw, params, query, nil)
+	ctx.router = r
+	defer putContextToPool(ctx)
+
+	// Registered before the panic handler's defer so hooks run after it.
+	if len(r.after) > 0 {
+		defer r.runAfterResponse(ctx)
 	}
 
-	ctx := getContextFromPool(req, rw, params, query, body)
-	defer putContextToPool(ctx)
+	if r.onPanic != nil && ctx.recoverPanics() {
+		defer func() {
+			if v := recover(); v != nil {
+				r.onPanic(ctx, v)
+			}
+		}()
+	}
 
-	handler, found := r.findHandler(root, path, params)
+	if r.autoParse {
+		ctx.BodyMap()
+	}
+
+	var handler Handler
+	found := false
+	if root != nil {
+		handler, found = r.findHandler(root, path, params)
+	}
+	if !found && method == http.MethodOptions {
+		if allowed := r.AllowedMethods(path); len(allowed) > 0 {
+			handler, found = optionsHandler(allowed), true
+		}
+	}
 	if !found {
+		if notFound := r.groupNotFound(path); notFound != nil {
+			notFound(ctx)
+			return
+		}
+	}
+	if !found && r.fallback != nil {
+		handler, found = r.fallback, true
+	}
+	if !found {
+		if r.devMode {
+			if suggestion := r.suggestRoute(path); suggestion != "" {
+				fmt.Printf("\033[33m404\033[0m  %-7s %s  did you mean %s?\n", method, path, suggestion)
+				ctx.Set(suggestionKey, suggestion)
+			} else {
+				fmt.Printf("\033[33m404\033[0m  %-7s %s\n", method, path)
+			}
+		}
 		r.notFound(ctx)
 		return
 	}
 
 	h := handler
 	for i := len(r.middleware) - 1; i >= 0; i-- {
-		h = r.middleware[i](h)
+		h = r.middleware[i].mw(abortable(h))
 	}
 
-	if err := h(ctx); err != nil {
+	// Handlers that already responded, e.g. via MustBind, keep their response.
+	if err := h(ctx); err != nil && !ctx.Writer.written {
 		if routixErr, ok := err.(*Error); ok {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(routixErr.Code)
-			json.NewEncoder(w).Encode(routixErr.ToResponse())
+			ctx.envelope(routixErr.Code, ctx.errorResponse(routixErr))
 		} else {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			http.Error(ctx.Writer, err.Error(), GetHTTPStatusCode(err))
 		}
 	}
 }
 
 func (r *Router) findHandler(root *node, path string, params map[string]string) (Handler, bool) {
+	if n := r.findNode(root, path, params); n != nil {
+		return n.handler, true
+	}
+	return nil, false
+}
+
+// findNode returns the node with a handler matching path, filling params,
+// or nil.
+func (r *Router) findNode(root *node, path string, params map[string]string) *node {
 	if path == "/" {
 		if root.handler != nil {
-			return root.handler, true
+			return root
 		}
-		return nil, false
+		return nil
 	}
 
 	pathLen := len(path)
@@ -398,7 +837,7 @@ func (r *Router) findHandler(root *node, path string, params map[string]string)
 		}
 
 		if child, ok := current.children[":"]; ok {
-			if len(child.params) > 0 {
+			if len(child.params) > 0 && len(params) < r.maxParams {
 				params[child.params[0]] = part
 			}
 			current = child
@@ -412,13 +851,77 @@ func (r *Router) findHandler(root *node, path string, params map[string]string)
 			break
 		}
 
-		return nil, false
+		return nil
 	}
 
 	if current.handler != nil {
-		return current.handler, true
+		return current
 	}
-	return nil, false
+	return nil
+}
+
+// suggestionKey holds the dev-mode "did you mean" route for a 404.
+const suggestionKey = "routix.suggestion"
+
+// suggestRoute returns the registered route pattern closest to path by edit
+// distance, or "" when nothing is close enough to be a plausible typo.
+// Parameter segments in a pattern match whatever the request has there.
+func (r *Router) suggestRoute(path string) string {
+	best, bestDist := "", -1
+	for _, route := range r.Routes() {
+		dist := levenshtein(path, expandPattern(route.Path, path))
+		if bestDist == -1 || dist < bestDist {
+			best, bestDist = route.Path, dist
+		}
+	}
+
+	limit := len(path) / 3
+	if limit < 2 {
+		limit = 2
+	}
+	if bestDist == -1 || bestDist > limit {
+		return ""
+	}
+	return best
+}
+
+// expandPattern fills a pattern's :param and * segments with the
+// corresponding segments of path so the two can be compared literally.
+func expandPattern(pattern, path string) string {
+	patternParts := strings.Split(pattern, "/")
+	pathParts := strings.Split(path, "/")
+	for i, part := range patternParts {
+		if i >= len(pathParts) {
+			break
+		}
+		switch {
+		case part == "*":
+			patternParts[i] = strings.Join(pathParts[i:], "/")
+		case strings.HasPrefix(part, ":"):
+			patternParts[i] = pathParts[i]
+		}
+	}
+	return strings.Join(patternParts, "/")
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
 }
 
 // Group returns a new route group with the given prefix.
@@ -430,51 +933,163 @@ func (r *Router) Group(prefix string) *Group {
 type Group struct {
 	router     *Router
 	prefix     string
-	middleware []Middleware
+	middleware []namedMiddleware
 }
 
 // Use appends middleware to this group and returns the group for chaining.
 func (g *Group) Use(middleware ...Middleware) *Group {
-	g.middleware = append(g.middleware, middleware...)
+	g.middleware = append(g.middleware, nameMiddleware(middleware)...)
+	return g
+}
+
+// UseNamed appends group middleware reported as name in route listings.
+func (g *Group) UseNamed(name string, mw Middleware) *Group {
+	g.middleware = append(g.middleware, namedMiddleware{name, mw})
+	return g
+}
+
+type groupNotFound struct {
+	prefix  string
+	handler Handler
+}
+
+// NotFound sets the handler for requests under the group's prefix that match
+// no route, taking precedence over Router.NotFound and Router.Fallback.
+// The most specific group wins when groups are nested.
+func (g *Group) NotFound(handler Handler) *Group {
+	prefix := strings.TrimSuffix(g.prefix, "/")
+	g.router.mu.Lock()
+	defer g.router.mu.Unlock()
+	for i, miss := range g.router.groupMiss {
+		if miss.prefix == prefix {
+			g.router.groupMiss[i].handler = handler
+			return g
+		}
+	}
+	g.router.groupMiss = append(g.router.groupMiss, groupNotFound{prefix: prefix, handler: handler})
 	return g
 }
 
-func (g *Group) applyMiddleware(handler Handler) Handler {
-	for i := len(g.middleware) - 1; i >= 0; i-- {
-		handler = g.middleware[i](handler)
+// groupNotFound returns the NotFound handler of the longest group prefix
+// containing path, or nil.
+func (r *Router) groupNotFound(path string) Handler {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var best Handler
+	bestLen := -1
+	for _, miss := range r.groupMiss {
+		if (path == miss.prefix || strings.HasPrefix(path, miss.prefix+"/")) && len(miss.prefix) > bestLen {
+			best, bestLen = miss.handler, len(miss.prefix)
+		}
+	}
+	return best
+}
+
+// chain returns the group's current middleware as one Middleware, so
+// route-level middleware added later can be slotted inside it.
+func (g *Group) chain() Middleware {
+	middleware := append([]namedMiddleware{}, g.middleware...)
+	return func(handler Handler) Handler {
+		for i := len(middleware) - 1; i >= 0; i-- {
+			handler = middleware[i].mw(abortable(handler))
+		}
+		return handler
 	}
-	return handler
 }
 
 // Group creates a sub-group nested under this group's prefix.
 func (g *Group) Group(prefix string) *Group {
 	return &Group{
 		router:     g.router,
-		prefix:     g.prefix + prefix,
-		middleware: append([]Middleware{}, g.middleware...),
+		prefix:     joinPaths(g.prefix, prefix),
+		middleware: append([]namedMiddleware{}, g.middleware...),
 	}
 }
 
-func (g *Group) Handle(method, path string, handler Handler) {
-	g.router.Handle(method, g.prefix+path, g.applyMiddleware(handler))
+// Handle registers a handler under the group's prefix, wrapped in the
+// group's middleware. Middleware passed after the handler applies to this
+// route only and runs inside the group's.
+func (g *Group) Handle(method, path string, handler Handler, middleware ...Middleware) *Route {
+	chain := g.chain()
+	rt := g.router.addRoute(method, joinPaths(g.prefix, path), chain(handler), middlewareNames(g.middleware))
+	rt.handler, rt.group = handler, chain
+	return rt.with(middleware)
 }
 
-func (g *Group) GET(path string, handler Handler)     { g.Handle(http.MethodGet, path, handler) }
-func (g *Group) POST(path string, handler Handler)    { g.Handle(http.MethodPost, path, handler) }
-func (g *Group) PUT(path string, handler Handler)     { g.Handle(http.MethodPut, path, handler) }
-func (g *Group) DELETE(path string, handler Handler)  { g.Handle(http.MethodDelete, path, handler) }
-func (g *Group) PATCH(path string, handler Handler)   { g.Handle(http.MethodPatch, path, handler) }
-func (g *Group) HEAD(path string, handler Handler)    { g.Handle(http.MethodHead, path, handler) }
-func (g *Group) OPTIONS(path string, handler Handler) { g.Handle(http.MethodOptions, path, handler) }
+// joinPaths appends path to a group prefix, collapsing the duplicate slashes
+// a trailing-slash prefix or doubled separators would leave. An empty path
+// names the prefix itself, without a trailing slash; a trailing slash on a
+// non-empty path is kept.
+func joinPaths(prefix, path string) string {
+	joined := prefix + "/" + path
+	var b strings.Builder
+	b.Grow(len(joined))
+	for i := 0; i < len(joined); i++ {
+		if joined[i] == '/' && i > 0 && joined[i-1] == '/' {
+			continue
+		}
+		b.WriteByte(joined[i])
+	}
+	out := b.String()
+	if len(out) > 1 && (path == "" || path == "/") {
+		out = strings.TrimSuffix(out, "/")
+	}
+	return out
+}
+
+func (g *Group) GET(path string, handler Handler, middleware ...Middleware) *Route {
+	return g.Handle(http.MethodGet, path, handler, middleware...)
+}
+
+func (g *Group) POST(path string, handler Handler, middleware ...Middleware) *Route {
+	return g.Handle(http.MethodPost, path, handler, middleware...)
+}
+
+func (g *Group) PUT(path string, handler Handler, middleware ...Middleware) *Route {
+	return g.Handle(http.MethodPut, path, handler, middleware...)
+}
+
+func (g *Group) DELETE(path string, handler Handler, middleware ...Middleware) *Route {
+	return g.Handle(http.MethodDelete, path, handler, middleware...)
+}
+
+func (g *Group) PATCH(path string, handler Handler, middleware ...Middleware) *Route {
+	return g.Handle(http.MethodPatch, path, handler, middleware...)
+}
+
+func (g *Group) HEAD(path string, handler Handler, middleware ...Middleware) *Route {
+	return g.Handle(http.MethodHead, path, handler, middleware...)
+}
+
+func (g *Group) OPTIONS(path string, handler Handler, middleware ...Middleware) *Route {
+	return g.Handle(http.MethodOptions, path, handler, middleware...)
+}
 
 // Context response helpers
 
 func (c *Context) SetHeader(key, value string) { c.Response.Header().Set(key, value) }
+func (c *Context) AddHeader(key, value string) { c.Response.Header().Add(key, value) }
+func (c *Context) DelHeader(key string)        { c.Response.Header().Del(key) }
 func (c *Context) GetHeader(key string) string  { return c.Request.Header.Get(key) }
 
+// Headers returns the response headers for bulk manipulation. Changes made
+// after the status has been written are not sent.
+func (c *Context) Headers() http.Header { return c.Response.Header() }
+
 func (c *Context) Cookie(name string) (*http.Cookie, error) { return c.Request.Cookie(name) }
+func (c *Context) Cookies() []*http.Cookie                  { return c.Request.Cookies() }
 func (c *Context) SetCookie(cookie *http.Cookie)             { http.SetCookie(c.Response, cookie) }
 
+// CookieValue returns the value of the named request cookie, or def when
+// the cookie is absent.
+func (c *Context) CookieValue(name, def string) string {
+	cookie, err := c.Request.Cookie(name)
+	if err != nil {
+		return def
+	}
+	return cookie.Value
+}
+
 func (c *Context) String(status int, format string, values ...any) error {
 	c.Response.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	c.Response.WriteHeader(status)
@@ -495,13 +1110,51 @@ func (c *Context) Redirect(status int, url string) error {
 	return nil
 }
 
+// AbortRedirect redirects the client to url and aborts the request, so
+// middleware calling it stops the handlers after it from running even if it
+// goes on to call next. It suits web pages where an unauthenticated user
+// should land on a login form rather than get a JSON error.
+func (c *Context) AbortRedirect(status int, url string) error {
+	c.aborted = true
+	return c.Redirect(status, url)
+}
+
+// IsAborted reports whether AbortRedirect was called for this request.
+func (c *Context) IsAborted() bool {
+	return c.aborted
+}
+
+// abortable skips next once the request has been aborted.
+func abortable(next Handler) Handler {
+	return func(c *Context) error {
+		if c.aborted {
+			return nil
+		}
+		return next(c)
+	}
+}
+
 // Start listens on addr and handles graceful shutdown on SIGINT/SIGTERM.
+// SIGHUP runs the callbacks registered with OnReload.
 func (r *Router) Start(addr string) error {
 	if len(r.trees) == 0 {
 		r.GET("/", WelcomeHandler("Routix"))
 	}
 
-	printBanner(addr, false)
+	if file := os.Getenv(ExportSpecEnv); file != "" {
+		return exportSpec(r, file)
+	}
+	if file := os.Getenv(ExportRoutesEnv); file != "" {
+		return exportRoutes(r, file)
+	}
+
+	if err := r.checkStart(); err != nil {
+		return err
+	}
+
+	if r.showBanner() {
+		printBanner(addr, false)
+	}
 
 	srv := &http.Server{
 		Addr:         addr,
@@ -511,12 +1164,54 @@ func (r *Router) Start(addr string) error {
 		IdleTimeout:  60 * time.Second,
 	}
 
-	return listenAndServe(srv)
+	return listenAndServe(srv, r.Reload)
 }
 
-func listenAndServe(srv *http.Server) error {
+// AfterResponse registers fn to run once the handler and middleware have
+// finished, for flushing metrics, audit logging or releasing resources.
+// Hooks run in registration order, also for requests that panicked, after
+// any PanicHandler. Responses served from CacheResponseFor skip them. A
+// panic in a hook is recovered so later hooks still run.
+func (r *Router) AfterResponse(fn func(c *Context)) *Router {
+	r.after = append(r.after, fn)
+	return r
+}
+
+func (r *Router) runAfterResponse(c *Context) {
+	for _, fn := range r.after {
+		func() {
+			defer func() { recover() }()
+			fn(c)
+		}()
+	}
+}
+
+// OnReload registers fn to run when the server receives SIGHUP, e.g. to
+// re-read configuration or reopen log files without a restart.
+func (r *Router) OnReload(fn func()) *Router {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reloaders = append(r.reloaders, fn)
+	return r
+}
+
+// Reload runs the OnReload callbacks in registration order. Start calls it
+// on SIGHUP; call it directly to trigger a reload another way.
+func (r *Router) Reload() {
+	r.mu.RLock()
+	reloaders := append([]func(){}, r.reloaders...)
+	r.mu.RUnlock()
+	for _, fn := range reloaders {
+		fn()
+	}
+}
+
+func listenAndServe(srv *http.Server, reload func()) error {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
 
 	errCh := make(chan error, 1)
 	go func() {
@@ -525,17 +1220,69 @@ func listenAndServe(srv *http.Server) error {
 		}
 	}()
 
-	select {
-	case err := <-errCh:
-		return err
-	case <-quit:
-		fmt.Println("\nshutting down...")
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-		return srv.Shutdown(ctx)
+	for {
+		select {
+		case err := <-errCh:
+			return err
+		case <-hup:
+			reload()
+		case <-quit:
+			fmt.Println("\nshutting down...")
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			return srv.Shutdown(ctx)
+		}
 	}
 }
 
+// NoBannerEnv names the environment variable that, set to any non-empty
+// value, stops Start from printing the startup banner.
+const NoBannerEnv = "ROUTIX_NO_BANNER"
+
+// SetVersion records the application version and build time, usually set
+// with -ldflags at build time, for the health endpoint to report.
+func (r *Router) SetVersion(version, buildTime string) *Router {
+	r.version = version
+	r.buildTime = buildTime
+	return r
+}
+
+// PanicHandler makes ServeHTTP recover panics raised by middleware and
+// handlers and pass the panic value to fn, which writes the response.
+// Without it, panics reach net/http unless Recovery is installed.
+func (r *Router) PanicHandler(fn func(c *Context, v interface{})) *Router {
+	r.onPanic = fn
+	return r
+}
+
+// DisableRecovery lets panics propagate out of ServeHTTP for this router,
+// past Recovery, RecoverToError and PanicHandler, so tests see them.
+func (r *Router) DisableRecovery() *Router {
+	r.noRecover = true
+	return r
+}
+
+// AutoParseBody makes every JSON request body decode into c.Body before
+// the handler runs. It is off by default: handlers that need the map call
+// c.BodyMap, which decodes on first use, and Bind decodes straight into
+// its target, so bodies aren't parsed twice or when nobody reads them.
+func (r *Router) AutoParseBody(enabled bool) *Router {
+	r.autoParse = enabled
+	return r
+}
+
+// Quiet stops Start from printing the startup banner, for containers and
+// other environments where stdout goes to a log collector. Setting
+// ROUTIX_NO_BANNER has the same effect.
+func (r *Router) Quiet() *Router {
+	r.quiet = true
+	return r
+}
+
+func (r *Router) showBanner() bool {
+	return !r.quiet && os.Getenv(NoBannerEnv) == ""
+}
+
 func printBanner(addr string, devMode bool) {
 	fmt.Println()
 	fmt.Print("\033[32m")
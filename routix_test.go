@@ -1,9 +1,25 @@
 package routix_test
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -324,3 +340,3853 @@ func TestRecoveryMiddleware(t *testing.T) {
 		t.Fatalf("expected 500 after panic recovery got %d", w.Code)
 	}
 }
+
+func TestOpenAPISpec(t *testing.T) {
+	r := routix.New()
+	r.GET("/users/:id", func(c *routix.Context) error { return nil })
+
+	paths := r.OpenAPISpec()["paths"].(map[string]interface{})
+	item, ok := paths["/users/{id}"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected /users/{id} in spec paths, got %v", paths)
+	}
+	if _, ok := item["get"]; !ok {
+		t.Fatal("expected get operation")
+	}
+}
+
+func TestSetPaginationHeaders(t *testing.T) {
+	r := routix.New()
+	r.GET("/items", func(c *routix.Context) error {
+		c.SetPaginationHeaders(3, 10, 95)
+		return c.JSON(200, nil)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "http://api.example.com/items?page=3&per_page=10&sort=name", ""))
+
+	if got := w.Header().Get("X-Total-Count"); got != "95" {
+		t.Fatalf("expected X-Total-Count 95 got %q", got)
+	}
+
+	link := w.Header().Get("Link")
+	want := []string{
+		`<http://api.example.com/items?page=1&per_page=10&sort=name>; rel="first"`,
+		`<http://api.example.com/items?page=2&per_page=10&sort=name>; rel="prev"`,
+		`<http://api.example.com/items?page=4&per_page=10&sort=name>; rel="next"`,
+		`<http://api.example.com/items?page=10&per_page=10&sort=name>; rel="last"`,
+	}
+	for _, part := range want {
+		if !strings.Contains(link, part) {
+			t.Errorf("Link header missing %s\ngot: %s", part, link)
+		}
+	}
+}
+
+func TestLocale(t *testing.T) {
+	r := routix.New()
+	var locale string
+	var stored any
+	r.GET("/", func(c *routix.Context) error {
+		locale = c.Locale([]string{"en", "fr", "de"}, "en")
+		stored, _ = c.Get(routix.LocaleKey)
+		return nil
+	})
+
+	req := newRequest("GET", "/", "")
+	req.Header.Set("Accept-Language", "es;q=0.9, fr-CA;q=0.8, de;q=0.5, *;q=0.1")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if locale != "fr" {
+		t.Fatalf("expected fr got %q", locale)
+	}
+	if stored != "fr" {
+		t.Fatalf("expected locale stored on context, got %v", stored)
+	}
+
+	req = newRequest("GET", "/", "")
+	req.Header.Set("Accept-Language", "ja, zh;q=0.7")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if locale != "en" {
+		t.Fatalf("expected default en got %q", locale)
+	}
+}
+
+// fakeProto is a stand-in for a generated protobuf message.
+type fakeProto struct {
+	payload []byte
+}
+
+func (m *fakeProto) Marshal() ([]byte, error) { return m.payload, nil }
+
+func (m *fakeProto) Unmarshal(data []byte) error {
+	m.payload = append([]byte(nil), data...)
+	return nil
+}
+
+func TestProtobufRoundTrip(t *testing.T) {
+	r := routix.New()
+	r.POST("/echo", func(c *routix.Context) error {
+		var in fakeProto
+		if err := c.ParseProtobuf(&in); err != nil {
+			return err
+		}
+		return c.Protobuf(200, &in)
+	})
+
+	payload := []byte{0x08, 0x96, 0x01, 0x12, 0x02, 'h', 'i'}
+	req := httptest.NewRequest("POST", "/echo", strings.NewReader(string(payload)))
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != 200 {
+		t.Fatalf("expected 200 got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-protobuf" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	if w.Body.String() != string(payload) {
+		t.Fatalf("payload not round-tripped: %v", w.Body.Bytes())
+	}
+}
+
+// fakeMsgPack tags JSON with a marker byte so tests can tell it was used.
+type fakeMsgPack struct{}
+
+func (fakeMsgPack) Marshal(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	return append([]byte{0xde}, data...), err
+}
+
+func (fakeMsgPack) Unmarshal(data []byte, v interface{}) error {
+	if len(data) == 0 || data[0] != 0xde {
+		return routix.BadRequest("not msgpack", nil)
+	}
+	return json.Unmarshal(data[1:], v)
+}
+
+func TestMsgPackRoundTrip(t *testing.T) {
+	routix.SetMsgPackCodec(fakeMsgPack{})
+	t.Cleanup(func() { routix.SetMsgPackCodec(nil) })
+
+	type item struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+
+	r := routix.New()
+	r.POST("/items", func(c *routix.Context) error {
+		var in item
+		if err := c.Bind(&in); err != nil {
+			return err
+		}
+		in.Count++
+		return c.MsgPack(200, in)
+	})
+
+	body, _ := fakeMsgPack{}.Marshal(item{Name: "widget", Count: 1})
+	req := httptest.NewRequest("POST", "/items", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/msgpack")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != 200 {
+		t.Fatalf("expected 200 got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/msgpack" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+
+	var out item
+	if err := (fakeMsgPack{}).Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "widget" || out.Count != 2 {
+		t.Fatalf("unexpected round-trip result: %+v", out)
+	}
+}
+
+func TestContextCopyInGoroutine(t *testing.T) {
+	r := routix.New()
+	release := make(chan struct{})
+	result := make(chan string, 1)
+
+	r.GET("/jobs/:id", func(c *routix.Context) error {
+		c.Set("user", "alice")
+		cp := c.Copy()
+		go func() {
+			<-release
+			user, _ := cp.Get("user")
+			got := cp.Param("id") + ":" + user.(string) + ":" + cp.QueryParam("mode")
+			if cp.Request.Context().Err() != nil {
+				got += ":cancelled"
+			}
+			result <- got
+		}()
+		return c.JSON(202, nil)
+	})
+	r.GET("/other/:id", func(c *routix.Context) error {
+		c.Set("user", "bob")
+		return c.JSON(200, nil)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), newRequest("GET", "/jobs/7?mode=fast", ""))
+	// Recycle pooled contexts and param maps with different values.
+	for i := 0; i < 10; i++ {
+		r.ServeHTTP(httptest.NewRecorder(), newRequest("GET", "/other/99?mode=slow", ""))
+	}
+	close(release)
+
+	if got := <-result; got != "7:alice:fast" {
+		t.Fatalf("copied context changed after recycling: %q", got)
+	}
+}
+
+func TestNotFoundSuggestion(t *testing.T) {
+	r := routix.New().EnableDevMode()
+	r.GET("/users/:id", func(c *routix.Context) error { return nil })
+	r.GET("/orders", func(c *routix.Context) error { return nil })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/usres/42", ""))
+	if w.Code != 404 {
+		t.Fatalf("expected 404 got %d", w.Code)
+	}
+
+	var body map[string]any
+	json.NewDecoder(w.Body).Decode(&body)
+	if body["suggestion"] != "/users/:id" {
+		t.Fatalf("expected suggestion /users/:id got %v", body["suggestion"])
+	}
+
+	// Outside dev mode the response body carries no suggestion.
+	prod := routix.New()
+	prod.GET("/users/:id", func(c *routix.Context) error { return nil })
+	w = httptest.NewRecorder()
+	prod.ServeHTTP(w, newRequest("GET", "/usres/42", ""))
+	body = nil
+	json.NewDecoder(w.Body).Decode(&body)
+	if _, ok := body["suggestion"]; ok {
+		t.Fatal("suggestion leaked outside dev mode")
+	}
+}
+
+func TestBindValidated(t *testing.T) {
+	type signup struct {
+		Email string `json:"email" validate:"required,email"`
+		Plan  string `json:"plan"`
+	}
+	schema := routix.NewObjectSchema(map[string]routix.Schema{
+		"email": routix.NewStringSchema().Min(3),
+		"plan":  routix.NewEnumSchema("free", "pro"),
+	})
+
+	r := routix.New()
+	r.POST("/signup", func(c *routix.Context) error {
+		in, err := routix.BindValidated[signup](c, schema)
+		if err != nil {
+			return err
+		}
+		return c.JSON(201, in)
+	})
+
+	cases := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"valid", `{"email":"a@example.com","plan":"pro"}`, 201},
+		{"schema failure", `{"email":"a@example.com","plan":"gold"}`, 400},
+		{"struct tag failure", `{"email":"not-an-email","plan":"free"}`, 400},
+	}
+
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("POST", "/signup", tc.body))
+		if w.Code != tc.want {
+			t.Errorf("%s: expected %d got %d: %s", tc.name, tc.want, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestRequireHeadersAndContentType(t *testing.T) {
+	r := routix.New()
+	r.Use(routix.RequireHeaders("X-Tenant"), routix.RequireContentType("application/json"))
+	r.POST("/orders", func(c *routix.Context) error {
+		return c.JSON(201, nil)
+	})
+
+	req := newRequest("POST", "/orders", `{"id":1}`)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 400 {
+		t.Fatalf("missing header: expected 400 got %d", w.Code)
+	}
+
+	req = newRequest("POST", "/orders", `id=1`)
+	req.Header.Set("X-Tenant", "acme")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 415 {
+		t.Fatalf("wrong content type: expected 415 got %d", w.Code)
+	}
+
+	req = newRequest("POST", "/orders", `{"id":1}`)
+	req.Header.Set("X-Tenant", "acme")
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 201 {
+		t.Fatalf("valid request: expected 201 got %d", w.Code)
+	}
+}
+
+func TestVersionByAccept(t *testing.T) {
+	r := routix.New()
+	v := r.VersionByAccept("myapp")
+	v.Version(1).GET("/users", func(c *routix.Context) error {
+		return c.String(200, "v1")
+	})
+	v.Version(2).GET("/users", func(c *routix.Context) error {
+		return c.String(200, "v2")
+	})
+
+	cases := []struct {
+		accept string
+		want   string
+	}{
+		{"application/vnd.myapp.v1+json", "v1"},
+		{"application/vnd.myapp.v2+json", "v2"},
+		{"text/html, application/vnd.myapp.v1+json;q=0.9", "v1"},
+		{"application/json", "v2"},
+		{"application/vnd.myapp.v9+json", "v2"},
+		{"", "v2"},
+	}
+
+	for _, tc := range cases {
+		req := newRequest("GET", "/users", "")
+		if tc.accept != "" {
+			req.Header.Set("Accept", tc.accept)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Body.String() != tc.want {
+			t.Errorf("Accept %q: expected %s got %s", tc.accept, tc.want, w.Body.String())
+		}
+	}
+}
+
+func TestVersionedRouteMiddleware(t *testing.T) {
+	r := routix.New()
+	v := r.VersionByAccept("myapp")
+	legacy := func(next routix.Handler) routix.Handler {
+		return func(c *routix.Context) error {
+			c.SetHeader("Deprecation", "true")
+			return next(c)
+		}
+	}
+	v1 := v.Version(1).GET("/users", func(c *routix.Context) error {
+		return c.String(200, "v1")
+	}, legacy)
+	v2 := v.Version(2).GET("/users", func(c *routix.Context) error {
+		return c.String(200, "v2")
+	})
+	if v1 != v2 {
+		t.Fatal("versions of one path should share a Route")
+	}
+	v2.RateLimit(2, time.Minute)
+
+	var got []string
+	for _, accept := range []string{"application/vnd.myapp.v1+json", "application/vnd.myapp.v2+json", "application/vnd.myapp.v2+json"} {
+		req := newRequest("GET", "/users", "")
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		got = append(got, fmt.Sprintf("%d %s %s", w.Code, w.Body.String(), w.Header().Get("Deprecation")))
+	}
+	if got[0] != "200 v1 true" || got[1] != "200 v2 " || strings.HasPrefix(got[2], "200") {
+		t.Errorf("got %q: want v1 with its middleware, v2 without, then rate limited", got)
+	}
+}
+
+func TestDeprecationHeaders(t *testing.T) {
+	sunset := time.Date(2027, time.January, 15, 0, 0, 0, 0, time.UTC)
+
+	r := routix.New()
+	legacy := r.Group("/v1")
+	legacy.Use(routix.Deprecated(sunset, "https://example.com/migrate"))
+	legacy.GET("/users", func(c *routix.Context) error {
+		return c.JSON(200, nil)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/v1/users", ""))
+
+	if got := w.Header().Get("Deprecation"); got != "true" {
+		t.Errorf("expected Deprecation: true got %q", got)
+	}
+	if got := w.Header().Get("Sunset"); got != "Fri, 15 Jan 2027 00:00:00 GMT" {
+		t.Errorf("unexpected Sunset %q", got)
+	}
+	if got := w.Header().Get("Link"); got != `<https://example.com/migrate>; rel="deprecation"` {
+		t.Errorf("unexpected Link %q", got)
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	r := routix.New()
+	r.PUT("/docs/:id", func(c *routix.Context) error {
+		if !c.IfMatch(`"v2"`) {
+			return c.PreconditionFailed()
+		}
+		return c.JSON(200, nil)
+	})
+
+	req := newRequest("PUT", "/docs/1", `{}`)
+	req.Header.Set("If-Match", `"v1", "v2"`)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 200 {
+		t.Fatalf("matching If-Match: expected 200 got %d", w.Code)
+	}
+
+	req = newRequest("PUT", "/docs/1", `{}`)
+	req.Header.Set("If-Match", `"v1"`)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 412 {
+		t.Fatalf("stale If-Match: expected 412 got %d", w.Code)
+	}
+}
+
+func TestBufferBodyLeavesBodyForHandler(t *testing.T) {
+	var logged string
+	r := routix.New()
+	r.Use(func(next routix.Handler) routix.Handler {
+		return func(c *routix.Context) error {
+			data, err := c.BufferBody()
+			if err != nil {
+				return err
+			}
+			logged = string(data)
+			return next(c)
+		}
+	})
+	r.POST("/users", func(c *routix.Context) error {
+		var in struct {
+			Name string `json:"name"`
+		}
+		if err := c.ParseJSON(&in); err != nil {
+			return err
+		}
+		return c.JSON(201, in)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("POST", "/users", `{"name":"ada"}`))
+
+	if logged != `{"name":"ada"}` {
+		t.Fatalf("middleware saw %q", logged)
+	}
+	if w.Code != 201 || !strings.Contains(w.Body.String(), `"name":"ada"`) {
+		t.Fatalf("handler could not parse body: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestBufferBodyLimit(t *testing.T) {
+	old := routix.MaxBufferedBodySize
+	routix.MaxBufferedBodySize = 8
+	t.Cleanup(func() { routix.MaxBufferedBodySize = old })
+
+	r := routix.New()
+	r.POST("/upload", func(c *routix.Context) error {
+		_, err := c.BufferBody()
+		if err != routix.ErrBodyTooLarge {
+			t.Fatalf("expected ErrBodyTooLarge got %v", err)
+		}
+		return nil
+	})
+
+	req := httptest.NewRequest("POST", "/upload", strings.NewReader("0123456789"))
+	r.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestParseJSONErrors(t *testing.T) {
+	r := routix.New()
+	r.POST("/items", func(c *routix.Context) error {
+		var in map[string]any
+		if err := c.ParseJSON(&in); err != nil {
+			return c.JSON(routix.GetHTTPStatusCode(err), map[string]any{"error": err.Error()})
+		}
+		return c.JSON(200, in)
+	})
+
+	empty := httptest.NewRequest("POST", "/items", strings.NewReader(""))
+	empty.Header.Set("Content-Type", "application/json")
+
+	form := httptest.NewRequest("POST", "/items", strings.NewReader("a=1"))
+	form.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	cases := []struct {
+		name string
+		req  *http.Request
+		want int
+	}{
+		{"empty body", empty, 400},
+		{"wrong content type", form, 415},
+		{"valid json", newRequest("POST", "/items", `{"a":1}`), 200},
+	}
+
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, tc.req)
+		if w.Code != tc.want {
+			t.Errorf("%s: expected %d got %d: %s", tc.name, tc.want, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestTypedQueryHelpers(t *testing.T) {
+	r := routix.New()
+	r.GET("/search", func(c *routix.Context) error {
+		active, err := c.QueryBool("active")
+		if err != nil || !active {
+			t.Errorf("QueryBool: got %v, %v", active, err)
+		}
+		if _, err := c.QueryBool("bogus"); err == nil {
+			t.Error("QueryBool: expected error for invalid value")
+		}
+		if _, err := c.QueryBool("missing"); err == nil {
+			t.Error("QueryBool: expected error for missing value")
+		}
+		if !c.QueryBoolDefault("missing", true) || !c.QueryBoolDefault("bogus", true) {
+			t.Error("QueryBoolDefault: expected default")
+		}
+
+		since, err := c.QueryTime("since", "2006-01-02")
+		if err != nil || !since.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("QueryTime: got %v, %v", since, err)
+		}
+		if _, err := c.QueryTime("bogus", "2006-01-02"); err == nil {
+			t.Error("QueryTime: expected error for invalid value")
+		}
+
+		price, err := c.QueryFloat("price")
+		if err != nil || price != 9.5 {
+			t.Errorf("QueryFloat: got %v, %v", price, err)
+		}
+		if _, err := c.QueryFloat("bogus"); err == nil {
+			t.Error("QueryFloat: expected error for invalid value")
+		}
+		return nil
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), newRequest("GET", "/search?active=true&since=2024-01-01&price=9.5&bogus=abc", ""))
+}
+
+func TestAccessLog(t *testing.T) {
+	var entries []routix.AccessLogEntry
+	r := routix.New()
+	r.Use(routix.AccessLog(routix.AccessLoggerFunc(func(e routix.AccessLogEntry) {
+		entries = append(entries, e)
+	})))
+	r.GET("/users/:id", func(c *routix.Context) error {
+		return c.String(200, "hello")
+	})
+	r.GET("/fail", func(c *routix.Context) error {
+		return routix.NotFound("no such thing", nil)
+	})
+
+	req := newRequest("GET", "/users/1", "")
+	req.Header.Set("X-Request-ID", "req-123")
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	r.ServeHTTP(httptest.NewRecorder(), newRequest("GET", "/fail", ""))
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries got %d", len(entries))
+	}
+
+	e := entries[0]
+	if e.Method != "GET" || e.Path != "/users/1" || e.Status != 200 || e.Size != 5 {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+	if e.IP != "203.0.113.7" || e.RequestID != "req-123" || e.Error != "" {
+		t.Errorf("unexpected entry metadata: %+v", e)
+	}
+	if e.Time.IsZero() || e.Latency <= 0 {
+		t.Errorf("expected time and latency: %+v", e)
+	}
+
+	if entries[1].Status != 404 || entries[1].Error == "" {
+		t.Errorf("expected 404 with error, got %+v", entries[1])
+	}
+}
+
+func TestPerRouteTimeout(t *testing.T) {
+	r := routix.New()
+	var chained bool
+	r.GETTimeout("/slow", 20*time.Millisecond, func(c *routix.Context) error {
+		<-c.Request.Context().Done()
+		return c.JSON(200, "late")
+	}, func(next routix.Handler) routix.Handler {
+		return func(c *routix.Context) error {
+			chained = true
+			return next(c)
+		}
+	}).Cache(time.Minute)
+	r.GET("/fast", func(c *routix.Context) error {
+		time.Sleep(40 * time.Millisecond)
+		return c.JSON(200, nil)
+	})
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/slow", ""))
+	if elapsed := time.Since(start); elapsed > 80*time.Millisecond {
+		t.Fatalf("slow route was not cut off at its limit: %v", elapsed)
+	}
+	if w.Code != 503 || strings.Contains(w.Body.String(), "late") {
+		t.Fatalf("expected 503 timeout response, got %d %s", w.Code, w.Body.String())
+	}
+	if !chained {
+		t.Error("route middleware passed to GETTimeout did not run")
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/fast", ""))
+	if w.Code != 200 {
+		t.Fatalf("route without timeout was affected: %d", w.Code)
+	}
+}
+
+func TestRegisterDecoderCSV(t *testing.T) {
+	type person struct {
+		Name string
+		Age  string
+	}
+
+	r := routix.New()
+	r.RegisterDecoder("text/csv", func(body io.Reader, v interface{}) error {
+		rows, err := csv.NewReader(body).ReadAll()
+		if err != nil {
+			return err
+		}
+		out := v.(*[]person)
+		for _, row := range rows[1:] {
+			*out = append(*out, person{Name: row[0], Age: row[1]})
+		}
+		return nil
+	})
+
+	var got []person
+	r.POST("/people", func(c *routix.Context) error {
+		if err := c.Bind(&got); err != nil {
+			return err
+		}
+		return c.JSON(200, nil)
+	})
+
+	req := httptest.NewRequest("POST", "/people", strings.NewReader("name,age\nada,36\nalan,41\n"))
+	req.Header.Set("Content-Type", "text/csv; charset=utf-8")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != 200 {
+		t.Fatalf("expected 200 got %d: %s", w.Code, w.Body.String())
+	}
+	if len(got) != 2 || got[0].Name != "ada" || got[1].Age != "41" {
+		t.Fatalf("unexpected bound rows: %+v", got)
+	}
+}
+
+func TestJSONWithCharset(t *testing.T) {
+	type payload struct {
+		Name string `json:"name"`
+	}
+
+	r := routix.New()
+	r.POST("/items", func(c *routix.Context) error {
+		if !c.IsJSON() {
+			t.Error("IsJSON: expected true")
+		}
+		if c.BodyMap()["name"] != "ada" {
+			t.Errorf("decoded body: got %v", c.Body)
+		}
+		var bound, parsed payload
+		if err := c.Bind(&bound); err != nil {
+			return err
+		}
+		if err := c.ParseJSON(&parsed); err != nil {
+			return err
+		}
+		if bound.Name != "ada" || parsed.Name != "ada" {
+			t.Errorf("decoded %+v / %+v", bound, parsed)
+		}
+		return c.JSON(200, nil)
+	})
+
+	for _, ct := range []string{"application/json; charset=utf-8", "Application/JSON;charset=UTF-8"} {
+		req := httptest.NewRequest("POST", "/items", strings.NewReader(`{"name":"ada"}`))
+		req.Header.Set("Content-Type", ct)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != 200 {
+			t.Errorf("%s: expected 200 got %d: %s", ct, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestRoutesReportMiddlewareNames(t *testing.T) {
+	auth := func(next routix.Handler) routix.Handler {
+		return func(c *routix.Context) error {
+			c.Set("user", "ada")
+			return next(c)
+		}
+	}
+
+	r := routix.New()
+	r.Use(routix.Logger())
+	api := r.Group("/api")
+	api.UseNamed("auth", auth)
+	api.GET("/me", func(c *routix.Context) error {
+		return c.JSON(200, map[string]any{"user": c.MustGet("user")})
+	})
+	r.GET("/health", func(c *routix.Context) error { return c.JSON(200, nil) })
+
+	names := map[string][]string{}
+	for _, route := range r.Routes() {
+		names[route.Path] = route.Middleware
+	}
+	if got := names["/api/me"]; len(got) != 2 || got[0] != "routix.Logger" || got[1] != "auth" {
+		t.Fatalf("/api/me middleware: %v", got)
+	}
+	if got := names["/health"]; len(got) != 1 || got[0] != "routix.Logger" {
+		t.Fatalf("/health middleware: %v", got)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/api/me", ""))
+	if w.Code != 200 || !strings.Contains(w.Body.String(), "ada") {
+		t.Fatalf("named middleware did not run: %d %s", w.Code, w.Body.String())
+	}
+
+	// Start with ExportRoutesEnv writes the same table for route:list.
+	file := filepath.Join(t.TempDir(), "routes.json")
+	t.Setenv(routix.ExportRoutesEnv, file)
+	if err := r.Start(":0"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exported []struct {
+		Path       string   `json:"path"`
+		Middleware []string `json:"middleware"`
+	}
+	if err := json.Unmarshal(data, &exported); err != nil || len(exported) != 2 {
+		t.Fatalf("exported routes: %v %s", err, data)
+	}
+	if got := exported[0]; got.Path != "/api/me" || fmt.Sprint(got.Middleware) != "[routix.Logger auth]" {
+		t.Fatalf("exported /api/me: %+v", got)
+	}
+}
+
+func TestFieldNaming(t *testing.T) {
+	tests := []struct {
+		style routix.NamingStyle
+		want  string
+		not   string
+	}{
+		{routix.NamingPassthrough, "pageNumber", "page_number"},
+		{routix.NamingSnake, "page_number", "pageNumber"},
+		{routix.NamingCamel, "pageNumber", "page_number"},
+	}
+
+	for _, tt := range tests {
+		r := routix.New().FieldNaming(tt.style)
+		r.GET("/items", func(c *routix.Context) error {
+			return c.Paginated([]string{"a"}, 1, 3)
+		})
+		r.GET("/fail", func(c *routix.Context) error {
+			return routix.NotFound("missing", nil)
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("GET", "/items", ""))
+		var body struct {
+			Status    string         `json:"status"`
+			Timestamp string         `json:"timestamp"`
+			Data      map[string]any `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if body.Status != "success" || body.Timestamp == "" {
+			t.Errorf("style %d: unexpected envelope %s", tt.style, w.Body.String())
+		}
+		if _, ok := body.Data[tt.want]; !ok {
+			t.Errorf("style %d: expected key %q in %v", tt.style, tt.want, body.Data)
+		}
+		if _, ok := body.Data[tt.not]; ok {
+			t.Errorf("style %d: unexpected key %q in %v", tt.style, tt.not, body.Data)
+		}
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("GET", "/fail", ""))
+		if w.Code != 404 || !strings.Contains(w.Body.String(), `"code":404`) {
+			t.Errorf("style %d: unexpected error envelope %d %s", tt.style, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestMultiStatus(t *testing.T) {
+	r := routix.New()
+	r.POST("/users/batch", func(c *routix.Context) error {
+		var users []struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(c.Request.Body).Decode(&users); err != nil {
+			return err
+		}
+		results := make([]routix.ItemResult, 0, len(users))
+		for i, u := range users {
+			if u.Name == "" {
+				results = append(results, routix.ItemFailed(i, routix.BadRequest("name is required", nil)))
+				continue
+			}
+			results = append(results, routix.ItemOK(i, 201, map[string]string{"name": u.Name}))
+		}
+		return c.MultiStatus(results)
+	})
+
+	req := httptest.NewRequest("POST", "/users/batch", strings.NewReader(`[{"name":"ada"},{"name":""}]`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("expected 207 got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Results []routix.ItemResult `json:"results"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Results) != 2 {
+		t.Fatalf("expected 2 results got %d", len(body.Results))
+	}
+	if body.Results[0].Status != 201 || body.Results[0].Data == nil {
+		t.Errorf("item 0: %+v", body.Results[0])
+	}
+	if body.Results[1].Index != 1 || body.Results[1].Status != 400 || body.Results[1].Error != "name is required" {
+		t.Errorf("item 1: %+v", body.Results[1])
+	}
+}
+
+func TestHeaderHelpers(t *testing.T) {
+	r := routix.New()
+	r.GET("/", func(c *routix.Context) error {
+		c.AddHeader("Vary", "Accept")
+		c.AddHeader("Vary", "Accept-Encoding")
+		c.SetHeader("X-Debug", "1")
+		c.DelHeader("X-Debug")
+		c.Headers().Add("Link", `</a>; rel="next"`)
+		return c.JSON(200, nil)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/", ""))
+
+	if got := w.Header().Values("Vary"); len(got) != 2 || got[0] != "Accept" || got[1] != "Accept-Encoding" {
+		t.Errorf("Vary: %v", got)
+	}
+	if _, ok := w.Header()["X-Debug"]; ok {
+		t.Error("X-Debug should have been deleted")
+	}
+	if got := w.Header().Get("Link"); got != `</a>; rel="next"` {
+		t.Errorf("Link: %q", got)
+	}
+}
+
+func TestTrustedPlatform(t *testing.T) {
+	tests := []struct {
+		platform string
+		header   string
+		value    string
+		want     string
+	}{
+		{routix.PlatformCloudflare, "CF-Connecting-IP", "203.0.113.7", "203.0.113.7"},
+		{routix.PlatformGCP, "X-Appengine-User-Ip", "203.0.113.8", "203.0.113.8"},
+		{routix.PlatformAWS, "CloudFront-Viewer-Address", "203.0.113.9:46532", "203.0.113.9"},
+		{routix.PlatformFly, "Fly-Client-IP", "203.0.113.10", "203.0.113.10"},
+		{"X-Client-IP", "X-Client-IP", "203.0.113.11", "203.0.113.11"},
+	}
+
+	for _, tt := range tests {
+		r := routix.New().TrustedPlatform(tt.platform)
+		var got string
+		r.GET("/", func(c *routix.Context) error {
+			got = c.ClientIP()
+			return nil
+		})
+
+		req := newRequest("GET", "/", "")
+		req.Header.Set("X-Forwarded-For", "10.0.0.1")
+		req.Header.Set(tt.header, tt.value)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		if got != tt.want {
+			t.Errorf("%s: ClientIP = %q, want %q", tt.platform, got, tt.want)
+		}
+		if ip := routix.GetRealIPFor(req, tt.platform); ip != tt.want {
+			t.Errorf("%s: GetRealIPFor = %q, want %q", tt.platform, ip, tt.want)
+		}
+	}
+
+	// Without the platform header a forged X-Forwarded-For must not win.
+	r := routix.New().TrustedPlatform(routix.PlatformCloudflare)
+	var got string
+	r.GET("/", func(c *routix.Context) error {
+		got = c.ClientIP()
+		return nil
+	})
+	req := newRequest("GET", "/", "")
+	req.RemoteAddr = "198.51.100.4:51234"
+	req.Header.Set("X-Forwarded-For", "10.0.0.1")
+	req.Header.Set("X-Real-IP", "10.0.0.2")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if got != "198.51.100.4" {
+		t.Errorf("missing platform header: ClientIP = %q, want RemoteAddr host", got)
+	}
+	if ip := routix.GetRealIPFor(req, routix.PlatformCloudflare); ip != "198.51.100.4" {
+		t.Errorf("missing platform header: GetRealIPFor = %q, want RemoteAddr host", ip)
+	}
+}
+
+func TestRecoverToError(t *testing.T) {
+	r := routix.New()
+	r.Use(routix.ErrorHandler(), routix.RecoverToError())
+	r.GET("/boom", func(c *routix.Context) error {
+		panic("kaboom")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/boom", ""))
+
+	if w.Code != 500 {
+		t.Fatalf("expected 500 got %d", w.Code)
+	}
+	var body routix.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Code != 500 || body.Message != "Internal Server Error" || body.Error != "kaboom" {
+		t.Fatalf("unexpected envelope: %+v", body)
+	}
+}
+
+func TestSlowLog(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	r := routix.New()
+	r.Use(routix.SlowLog(20 * time.Millisecond))
+	r.GET("/slow", func(c *routix.Context) error {
+		time.Sleep(40 * time.Millisecond)
+		return c.JSON(200, nil)
+	})
+	r.GET("/fast", func(c *routix.Context) error { return c.JSON(200, nil) })
+
+	r.ServeHTTP(httptest.NewRecorder(), newRequest("GET", "/fast", ""))
+	if buf.Len() != 0 {
+		t.Fatalf("fast request logged: %s", buf.String())
+	}
+
+	req := newRequest("GET", "/slow", "")
+	req.Header.Set("X-Request-ID", "req-9")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	out := buf.String()
+	if !strings.Contains(out, "slow request GET /slow") || !strings.Contains(out, "request_id=req-9") {
+		t.Fatalf("unexpected slow log: %q", out)
+	}
+}
+
+func TestLogsUseTrustedPlatformIP(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	var entry routix.AccessLogEntry
+	r := routix.New().TrustedPlatform(routix.PlatformCloudflare)
+	r.Use(routix.AccessLog(routix.AccessLoggerFunc(func(e routix.AccessLogEntry) {
+		entry = e
+	})), routix.SlowLog(0))
+	var clientIP string
+	r.GET("/", func(c *routix.Context) error {
+		clientIP = c.ClientIP()
+		time.Sleep(time.Millisecond)
+		return c.JSON(200, nil)
+	})
+
+	req := newRequest("GET", "/", "")
+	req.Header.Set("CF-Connecting-IP", "203.0.113.7")
+	req.Header.Set("X-Forwarded-For", "10.6.6.6")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if entry.IP != "203.0.113.7" || entry.IP != clientIP {
+		t.Errorf("access log IP = %q, ClientIP = %q", entry.IP, clientIP)
+	}
+	if !strings.Contains(buf.String(), "ip=203.0.113.7") {
+		t.Errorf("slow log: %q", buf.String())
+	}
+}
+
+func TestCookies(t *testing.T) {
+	r := routix.New()
+	r.GET("/", func(c *routix.Context) error {
+		names := []string{}
+		for _, cookie := range c.Cookies() {
+			names = append(names, cookie.Name+"="+cookie.Value)
+		}
+		return c.JSON(200, map[string]any{
+			"cookies": names,
+			"theme":   c.CookieValue("theme", "light"),
+			"lang":    c.CookieValue("lang", "en"),
+		})
+	})
+
+	req := newRequest("GET", "/", "")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	req.AddCookie(&http.Cookie{Name: "lang", Value: "tr"})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var body struct {
+		Cookies []string `json:"cookies"`
+		Theme   string   `json:"theme"`
+		Lang    string   `json:"lang"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Cookies) != 2 || body.Cookies[0] != "session=abc" || body.Cookies[1] != "lang=tr" {
+		t.Errorf("Cookies: %v", body.Cookies)
+	}
+	if body.Theme != "light" || body.Lang != "tr" {
+		t.Errorf("CookieValue: theme=%q lang=%q", body.Theme, body.Lang)
+	}
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestCompressNegotiation(t *testing.T) {
+	r := routix.New()
+	r.Use(routix.Compress())
+	r.GET("/", func(c *routix.Context) error {
+		return c.String(200, "hello hello hello")
+	})
+
+	get := func(acceptEncoding string) *httptest.ResponseRecorder {
+		req := newRequest("GET", "/", "")
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("%q: missing Vary header", acceptEncoding)
+		}
+		return w
+	}
+
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"gzip", "gzip"},
+		{"", ""},
+		{"br", ""},
+		{"gzip;q=0, identity", ""},
+		{"deflate, gzip;q=0.5", "gzip"},
+	}
+	for _, tt := range tests {
+		if got := get(tt.accept).Header().Get("Content-Encoding"); got != tt.want {
+			t.Errorf("%q: Content-Encoding = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+
+	w := get("gzip")
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(gz); string(body) != "hello hello hello" {
+		t.Errorf("gzip body: %q", body)
+	}
+
+	routix.SetBrotliEncoder(func(w io.Writer) io.WriteCloser { return nopWriteCloser{w} })
+	defer routix.SetBrotliEncoder(nil)
+	if got := get("gzip, br").Header().Get("Content-Encoding"); got != "br" {
+		t.Errorf("expected br when an encoder is registered, got %q", got)
+	}
+	if got := get("gzip, br;q=0.5").Header().Get("Content-Encoding"); got != "gzip" {
+		t.Errorf("expected gzip to win on weight, got %q", got)
+	}
+}
+
+func TestStaticCacheHeaders(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"app.3f2a9c1e.js", "robots.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := routix.New()
+	r.StaticWithOptions("/assets", dir, routix.StaticOptions{
+		MaxAge:    time.Hour,
+		Immutable: true,
+		ETag:      true,
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/assets/app.3f2a9c1e.js", ""))
+	if w.Code != 200 {
+		t.Fatalf("expected 200 got %d", w.Code)
+	}
+	if got := w.Header().Get("Cache-Control"); got != "public, max-age=31536000, immutable" {
+		t.Errorf("fingerprinted Cache-Control: %q", got)
+	}
+	if w.Header().Get("Last-Modified") == "" {
+		t.Error("missing Last-Modified")
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("missing ETag")
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/assets/robots.txt", ""))
+	if got := w.Header().Get("Cache-Control"); got != "public, max-age=3600" {
+		t.Errorf("plain Cache-Control: %q", got)
+	}
+
+	req := newRequest("GET", "/assets/app.3f2a9c1e.js", "")
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for matching ETag got %d", w.Code)
+	}
+}
+
+func TestSafePath(t *testing.T) {
+	base := t.TempDir()
+	r := routix.New()
+	r.GET("/files/*", func(c *routix.Context) error {
+		p, err := c.SafePath(base)
+		if err != nil {
+			return routix.BadRequest("invalid path", err)
+		}
+		return c.JSON(200, map[string]string{"path": p})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/files/docs/a.txt", ""))
+	if w.Code != 200 || !strings.Contains(w.Body.String(), filepath.Join(base, "docs", "a.txt")) {
+		t.Fatalf("normal path: %d %s", w.Code, w.Body.String())
+	}
+
+	for _, p := range []string{
+		"/files/../../etc/passwd",
+		"/files/docs/../../../etc/passwd",
+		"/files/..%2f..%2fetc%2fpasswd",
+		"/files/%2e%2e/secret",
+		"/files/..",
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("GET", p, ""))
+		if w.Code != 400 {
+			t.Errorf("%s: expected 400 got %d", p, w.Code)
+		}
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(base, "secret.txt"), []byte("secret"), 0644)
+	r.Static("/public", dir)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/public/../"+filepath.Base(base)+"/secret.txt", ""))
+	if w.Code != 400 || strings.Contains(w.Body.String(), "secret\n") {
+		t.Errorf("Static traversal: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestPublishExpvars(t *testing.T) {
+	routix.PublishExpvars()
+	routix.PublishExpvars()
+
+	r := routix.New()
+	r.Use(routix.PerformanceMonitor())
+	r.GET("/ok", func(c *routix.Context) error { return c.JSON(200, nil) })
+	r.GET("/teapot", func(c *routix.Context) error { return routix.NewError(418, "teapot", nil) })
+	r.GET("/debug/vars", func(c *routix.Context) error {
+		expvar.Handler().ServeHTTP(c.Response, c.Request)
+		return nil
+	})
+
+	read := func() (total float64, statuses map[string]float64) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("GET", "/debug/vars", ""))
+		var vars struct {
+			Routix struct {
+				TotalRequests float64            `json:"total_requests"`
+				StatusCodes   map[string]float64 `json:"status_codes"`
+			} `json:"routix"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &vars); err != nil {
+			t.Fatal(err)
+		}
+		return vars.Routix.TotalRequests, vars.Routix.StatusCodes
+	}
+
+	total, statuses := read()
+	r.ServeHTTP(httptest.NewRecorder(), newRequest("GET", "/ok", ""))
+	r.ServeHTTP(httptest.NewRecorder(), newRequest("GET", "/teapot", ""))
+	after, afterStatuses := read()
+
+	// The first /debug/vars read is counted as well.
+	if after-total != 3 {
+		t.Errorf("total_requests went from %v to %v", total, after)
+	}
+	if afterStatuses["418"]-statuses["418"] != 1 {
+		t.Errorf("418 count: %v -> %v", statuses["418"], afterStatuses["418"])
+	}
+	if afterStatuses["200"]-statuses["200"] < 2 {
+		t.Errorf("200 count: %v -> %v", statuses["200"], afterStatuses["200"])
+	}
+}
+
+func TestStreamAttachment(t *testing.T) {
+	payload := strings.Repeat("routix", 10000)
+	r := routix.New()
+	r.GET("/download", func(c *routix.Context) error {
+		return c.StreamAttachment("report 2024.csv", int64(len(payload)), strings.NewReader(payload))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/download", ""))
+
+	if w.Code != 200 {
+		t.Fatalf("expected 200 got %d", w.Code)
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="report 2024.csv"` {
+		t.Errorf("Content-Disposition: %q", got)
+	}
+	if got := w.Header().Get("Content-Length"); got != "60000" {
+		t.Errorf("Content-Length: %q", got)
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+		t.Errorf("Content-Type: %q", w.Header().Get("Content-Type"))
+	}
+	if w.Body.String() != payload {
+		t.Errorf("body length %d, want %d", w.Body.Len(), len(payload))
+	}
+}
+
+func TestMountPprof(t *testing.T) {
+	r := routix.New()
+	r.GET("/", func(c *routix.Context) error { return c.JSON(200, nil) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/debug/pprof/", ""))
+	if w.Code != 404 {
+		t.Fatalf("expected 404 before mounting got %d", w.Code)
+	}
+
+	r.MountPprof("/debug/pprof")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/debug/pprof/", ""))
+	if w.Code != 200 || !strings.Contains(w.Body.String(), "goroutine") {
+		t.Fatalf("index: %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/debug/pprof/goroutine?debug=1", ""))
+	if w.Code != 200 || !strings.Contains(w.Body.String(), "goroutine profile") {
+		t.Fatalf("goroutine profile: %d", w.Code)
+	}
+}
+
+func TestMaxJSONDepth(t *testing.T) {
+	type payload struct {
+		Data any `json:"data"`
+	}
+
+	r := routix.New().MaxJSONDepth(10)
+	r.POST("/bind", func(c *routix.Context) error {
+		var p payload
+		if err := c.Bind(&p); err != nil {
+			return err
+		}
+		return c.JSON(200, nil)
+	})
+	r.POST("/parse", func(c *routix.Context) error {
+		var p payload
+		if err := c.ParseJSON(&p); err != nil {
+			return err
+		}
+		return c.JSON(200, nil)
+	})
+
+	nested := func(depth int) string {
+		return `{"data":` + strings.Repeat("[", depth-1) + strings.Repeat("]", depth-1) + `}`
+	}
+
+	for _, path := range []string{"/bind", "/parse"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("POST", path, nested(10)))
+		if w.Code != 200 {
+			t.Errorf("%s within limit: expected 200 got %d: %s", path, w.Code, w.Body.String())
+		}
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("POST", path, nested(5000)))
+		if w.Code != 400 || !strings.Contains(w.Body.String(), "maximum nesting depth") {
+			t.Errorf("%s beyond limit: expected 400 got %d: %s", path, w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("POST", "/bind", `{"data":"[[[[[[[[[[[[[[[[[[[[[[["}`))
+	if w.Code != 200 {
+		t.Errorf("brackets inside strings should not count: %d", w.Code)
+	}
+}
+
+func TestValidatorTimeAndDuration(t *testing.T) {
+	type booking struct {
+		StartsAt time.Time     `validate:"required,after=now,before=2100-01-01"`
+		Length   time.Duration `validate:"min=15m,max=4h"`
+	}
+
+	valid := booking{StartsAt: time.Now().Add(24 * time.Hour), Length: time.Hour}
+	if err := routix.ValidateStruct(&valid); err != nil {
+		t.Fatalf("expected future booking to pass, got %v", err)
+	}
+
+	past := booking{StartsAt: time.Now().Add(-time.Hour), Length: time.Hour}
+	err := routix.ValidateStruct(&past)
+	if err == nil || !strings.Contains(err.Error(), "StartsAt: must be after now") {
+		t.Fatalf("expected after=now failure, got %v", err)
+	}
+
+	short := booking{StartsAt: time.Now().Add(time.Hour), Length: time.Minute}
+	err = routix.ValidateStruct(&short)
+	if err == nil || !strings.Contains(err.Error(), "duration must be at least 15m0s") {
+		t.Fatalf("expected duration failure, got %v", err)
+	}
+
+	missing := booking{Length: time.Hour}
+	err = routix.ValidateStruct(&missing)
+	if err == nil || !strings.Contains(err.Error(), "StartsAt: field is required") {
+		t.Fatalf("expected required failure, got %v", err)
+	}
+}
+
+type dateRange struct {
+	From time.Time `validate:"required"`
+	To   time.Time `validate:"required"`
+}
+
+func (d dateRange) Validate() error {
+	if !d.To.After(d.From) {
+		return routix.ValidationErrors{routix.NewValidationError("To", "must be after From")}
+	}
+	return nil
+}
+
+func TestValidatableHook(t *testing.T) {
+	now := time.Now()
+
+	if err := routix.ValidateStruct(&dateRange{From: now, To: now.Add(time.Hour)}); err != nil {
+		t.Fatalf("expected valid range, got %v", err)
+	}
+
+	err := routix.ValidateStruct(&dateRange{From: now, To: now.Add(-time.Hour)})
+	if err == nil || !strings.Contains(err.Error(), "To: must be after From") {
+		t.Fatalf("expected cross-field failure, got %v", err)
+	}
+
+	v := routix.NewValidator()
+	if v.Validate(dateRange{From: now}) {
+		t.Fatal("expected failure")
+	}
+	if errs := v.Errors(); len(errs) != 2 || errs[0].Field != "To" || errs[1].Message != "must be after From" {
+		t.Fatalf("expected tag error then hook error, got %+v", errs)
+	}
+}
+
+func TestShouldAndMustBind(t *testing.T) {
+	type filter struct {
+		Page  int      `query:"page"`
+		Tags  []string `query:"tag"`
+		Since time.Duration
+	}
+
+	r := routix.New()
+	r.GET("/should", func(c *routix.Context) error {
+		var f filter
+		if err := c.ShouldBindQuery(&f); err != nil {
+			if c.Status() != 200 || c.Writer.Size() != 0 {
+				t.Error("ShouldBindQuery must not write a response")
+			}
+			return c.JSON(422, map[string]string{"custom": err.Error()})
+		}
+		return c.JSON(200, f)
+	})
+	r.GET("/must", func(c *routix.Context) error {
+		var f filter
+		if err := c.MustBindQuery(&f); err != nil {
+			return err
+		}
+		return c.JSON(200, f)
+	})
+	r.POST("/must-json", func(c *routix.Context) error {
+		var body struct {
+			Name string `json:"name"`
+		}
+		if err := c.MustBindJSON(&body); err != nil {
+			return err
+		}
+		return c.JSON(200, body)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/should?page=2&tag=a&tag=b&Since=90s", ""))
+	if w.Code != 200 || !strings.Contains(w.Body.String(), `"Page":2,"Tags":["a","b"],"Since":90000000000`) {
+		t.Fatalf("ShouldBindQuery: %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/should?page=two", ""))
+	if w.Code != 422 || !strings.Contains(w.Body.String(), "custom") {
+		t.Fatalf("ShouldBindQuery error should be left to the handler: %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/must?page=two", ""))
+	if w.Code != 400 || !strings.Contains(w.Body.String(), `page: invalid integer \"two\"`) {
+		t.Fatalf("MustBindQuery: %d %s", w.Code, w.Body.String())
+	}
+
+	req := httptest.NewRequest("POST", "/must-json", strings.NewReader(`{"name":`))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 400 || strings.Count(w.Body.String(), "\n") != 1 {
+		t.Fatalf("MustBindJSON should write exactly one response: %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestRouteSchemas(t *testing.T) {
+	user := routix.NewObjectSchema(map[string]routix.Schema{
+		"name": routix.NewStringSchema().Min(3),
+		"age":  routix.NewNumberSchema().Integer().Min(0),
+	}).Strict()
+
+	r := routix.New()
+	r.POST("/users", func(c *routix.Context) error {
+		return c.JSON(201, c.Body)
+	}).Request(user).Response(201, user)
+
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`{"name":"alice","age":30}`, 201},
+		{`{"name":"al"}`, 400},
+		{`{"name":"alice","admin":true}`, 400},
+		{`{"name":"alice","age":1.5}`, 400},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("POST", "/users", tt.body))
+		if w.Code != tt.code {
+			t.Errorf("%s: expected %d got %d: %s", tt.body, tt.code, w.Code, w.Body.String())
+		}
+	}
+
+	spec := r.OpenAPISpec()
+	op := spec["paths"].(map[string]interface{})["/users"].(map[string]interface{})["post"].(map[string]interface{})
+
+	reqSchema := op["requestBody"].(map[string]interface{})["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"].(map[string]interface{})
+	if reqSchema["type"] != "object" || reqSchema["additionalProperties"] != false {
+		t.Fatalf("unexpected request schema: %v", reqSchema)
+	}
+	name := reqSchema["properties"].(map[string]interface{})["name"].(map[string]interface{})
+	if name["type"] != "string" || name["minLength"] != 3 {
+		t.Fatalf("unexpected name schema: %v", name)
+	}
+
+	responses := op["responses"].(map[string]interface{})
+	created, ok := responses["201"].(map[string]interface{})
+	if !ok || created["description"] != "Created" {
+		t.Fatalf("expected documented 201 response, got %v", responses)
+	}
+	if _, ok := responses["200"]; ok {
+		t.Fatalf("placeholder 200 response should be replaced: %v", responses)
+	}
+}
+
+func TestFallback(t *testing.T) {
+	r := routix.New()
+	r.Use(func(next routix.Handler) routix.Handler {
+		return func(c *routix.Context) error {
+			c.SetHeader("X-Served-By", "routix")
+			return next(c)
+		}
+	})
+	r.GET("/api/users", func(c *routix.Context) error {
+		return c.JSON(200, []string{"ada"})
+	})
+	r.GET("/api/users/:id", func(c *routix.Context) error {
+		return c.JSON(200, map[string]string{"id": c.Param("id")})
+	})
+	r.Fallback(func(c *routix.Context) error {
+		return c.HTML(200, "<div id=app></div>")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/api/users/7", ""))
+	if w.Code != 200 || !strings.Contains(w.Body.String(), `"id":"7"`) {
+		t.Fatalf("API route shadowed: %d %s", w.Code, w.Body.String())
+	}
+
+	for _, path := range []string{"/dashboard", "/settings/profile", "/"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("GET", path, ""))
+		if w.Code != 200 || !strings.Contains(w.Body.String(), "id=app") {
+			t.Errorf("%s: expected SPA shell got %d %s", path, w.Code, w.Body.String())
+		}
+		if w.Header().Get("X-Served-By") != "routix" {
+			t.Errorf("%s: fallback should run behind global middleware", path)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("DELETE", "/anything", ""))
+	if w.Code != 200 {
+		t.Errorf("fallback should also cover methods without routes, got %d", w.Code)
+	}
+}
+
+func TestGroupNotFound(t *testing.T) {
+	r := routix.New()
+	r.NotFound(func(c *routix.Context) error {
+		return c.HTML(404, "<h1>Page not found</h1>")
+	})
+
+	api := r.Group("/api")
+	api.GET("/users", func(c *routix.Context) error { return c.JSON(200, nil) })
+	api.NotFound(func(c *routix.Context) error {
+		return c.JSON(404, map[string]string{"error": "no such endpoint"})
+	})
+	v2 := api.Group("/v2")
+	v2.NotFound(func(c *routix.Context) error {
+		return c.JSON(404, map[string]string{"error": "v2 endpoint missing"})
+	})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/api/nope", "no such endpoint"},
+		{"/api/users/1/posts", "no such endpoint"},
+		{"/api/v2/nope", "v2 endpoint missing"},
+		{"/apix", "Page not found"},
+		{"/about", "Page not found"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("GET", tt.path, ""))
+		if w.Code != 404 || !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("%s: expected 404 %q got %d %s", tt.path, tt.want, w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/api/users", ""))
+	if w.Code != 200 {
+		t.Errorf("group route: expected 200 got %d", w.Code)
+	}
+}
+
+func TestRequestContext(t *testing.T) {
+	type ctxKey struct{}
+
+	r := routix.New()
+	r.Use(routix.Timeout(50 * time.Millisecond))
+	r.GET("/", func(c *routix.Context) error {
+		if c.RequestContext().Value(ctxKey{}) != "tenant-1" {
+			t.Error("RequestContext should carry the request's values")
+		}
+		if _, ok := c.RequestContext().Deadline(); !ok {
+			t.Error("RequestContext should carry the Timeout deadline")
+		}
+		return c.JSON(200, nil)
+	})
+
+	req := newRequest("GET", "/", "")
+	req = req.WithContext(context.WithValue(req.Context(), ctxKey{}, "tenant-1"))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 200 {
+		t.Fatalf("expected 200 got %d", w.Code)
+	}
+}
+
+func TestResourceOptionsAndHead(t *testing.T) {
+	list := func(c *routix.Context) error { return c.JSON(200, []string{"a", "b"}) }
+	r := routix.New()
+	r.Resource("/posts", routix.ResourceController{
+		Index:  list,
+		Create: list,
+		Show:   list,
+		Delete: list,
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("OPTIONS", "/posts", ""))
+	if w.Code != 204 || w.Header().Get("Allow") != "GET, HEAD, POST, OPTIONS" {
+		t.Errorf("collection OPTIONS: %d Allow=%q", w.Code, w.Header().Get("Allow"))
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("OPTIONS", "/posts/1", ""))
+	if w.Code != 204 || w.Header().Get("Allow") != "GET, HEAD, DELETE, OPTIONS" {
+		t.Errorf("item OPTIONS: %d Allow=%q", w.Code, w.Header().Get("Allow"))
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("HEAD", "/posts/1", ""))
+	if w.Code != 200 || w.Body.Len() != 0 || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		t.Errorf("HEAD: %d body=%q headers=%v", w.Code, w.Body.String(), w.Header())
+	}
+}
+
+func TestBindQueryDefaultsAndValidation(t *testing.T) {
+	type listParams struct {
+		Page  int    `query:"page" default:"1" validate:"min=1"`
+		Limit int    `query:"limit" default:"20" validate:"min=1,max=100"`
+		Sort  string `query:"sort" default:"created_at" validate:"enum=created_at|name"`
+	}
+
+	r := routix.New()
+	r.GET("/items", func(c *routix.Context) error {
+		var p listParams
+		if err := c.BindQuery(&p); err != nil {
+			return err
+		}
+		return c.JSON(200, p)
+	})
+
+	tests := []struct {
+		query string
+		code  int
+		want  string
+	}{
+		{"", 200, `{"Page":1,"Limit":20,"Sort":"created_at"}`},
+		{"?page=3&limit=50&sort=name", 200, `{"Page":3,"Limit":50,"Sort":"name"}`},
+		{"?limit=500", 422, "value must be at most 100"},
+		{"?limit=lots", 422, `invalid integer`},
+		{"?sort=price", 422, "must be one of"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("GET", "/items"+tt.query, ""))
+		if w.Code != tt.code || !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("%q: expected %d %s got %d %s", tt.query, tt.code, tt.want, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestStatusSuccessHelpers(t *testing.T) {
+	r := routix.New()
+	r.POST("/items", func(c *routix.Context) error { return c.Created(map[string]int{"id": 1}) })
+	r.POST("/jobs", func(c *routix.Context) error { return c.Accepted(map[string]string{"job": "j1"}) })
+	r.DELETE("/items/:id", func(c *routix.Context) error { return c.NoContent() })
+
+	for _, tt := range []struct {
+		method, path string
+		code         int
+		data         string
+	}{
+		{"POST", "/items", 201, `{"id":1}`},
+		{"POST", "/jobs", 202, `{"job":"j1"}`},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest(tt.method, tt.path, ""))
+		if w.Code != tt.code {
+			t.Errorf("%s: expected %d got %d", tt.path, tt.code, w.Code)
+		}
+		var body struct {
+			Status    string          `json:"status"`
+			Timestamp string          `json:"timestamp"`
+			Data      json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if body.Status != "success" || body.Timestamp == "" || string(body.Data) != tt.data {
+			t.Errorf("%s: unexpected envelope %s", tt.path, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("DELETE", "/items/1", ""))
+	if w.Code != 204 || w.Body.Len() != 0 {
+		t.Errorf("NoContent: %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestTimestampFormat(t *testing.T) {
+	tests := []struct {
+		layout string
+		parse  func(string) error
+	}{
+		{"", func(s string) error { _, err := time.Parse(time.RFC3339, s); return err }},
+		{time.RFC3339Nano, func(s string) error { _, err := time.Parse(time.RFC3339Nano, s); return err }},
+		{routix.TimestampUnix, func(s string) error {
+			if len(s) != 10 || strings.Trim(s, "0123456789") != "" {
+				return fmt.Errorf("not a unix timestamp: %q", s)
+			}
+			return nil
+		}},
+	}
+
+	for _, tt := range tests {
+		r := routix.New().TimestampFormat(tt.layout)
+		r.GET("/item", func(c *routix.Context) error {
+			return c.Created(map[string]int{"id": 1})
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("GET", "/item", ""))
+		var body struct {
+			Timestamp string `json:"timestamp"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if err := tt.parse(body.Timestamp); err != nil {
+			t.Errorf("layout %q: %v", tt.layout, err)
+		}
+	}
+}
+
+// captureStdout returns what fn writes to os.Stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+	w.Close()
+	out, _ := io.ReadAll(r)
+	return string(out)
+}
+
+func TestNewHasNoDefaultMiddleware(t *testing.T) {
+	r := routix.New()
+	r.GET("/ping", func(c *routix.Context) error {
+		return c.JSON(200, "pong")
+	})
+
+	out := captureStdout(t, func() {
+		r.ServeHTTP(httptest.NewRecorder(), newRequest("GET", "/ping", ""))
+		r.ServeHTTP(httptest.NewRecorder(), newRequest("GET", "/missing", ""))
+	})
+	if out != "" {
+		t.Errorf("expected no output from New(), got %q", out)
+	}
+}
+
+func TestDevModeFromAppEnv(t *testing.T) {
+	miss := func(r *routix.Router) string {
+		r.GET("/users", func(c *routix.Context) error { return nil })
+		return captureStdout(t, func() {
+			r.ServeHTTP(httptest.NewRecorder(), newRequest("GET", "/missing", ""))
+		})
+	}
+
+	t.Setenv("APP_ENV", "production")
+	if out := miss(routix.New()); out != "" {
+		t.Errorf("expected dev mode off by default, got %q", out)
+	}
+	if out := miss(routix.New().EnableDevMode()); !strings.Contains(out, "/missing") {
+		t.Errorf("expected EnableDevMode to print misses, got %q", out)
+	}
+
+	t.Setenv("APP_ENV", "development")
+	if out := miss(routix.New()); !strings.Contains(out, "/missing") {
+		t.Errorf("expected APP_ENV=development to print misses, got %q", out)
+	}
+}
+
+func TestBindMultipartFieldsAndFiles(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("title", "report")
+	mw.WriteField("pages", "3")
+	fw, err := mw.CreateFormFile("document", "report.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write([]byte("hello"))
+	mw.Close()
+
+	type upload struct {
+		Title    string                `form:"title"`
+		Pages    int                   `form:"pages"`
+		Document *multipart.FileHeader `file:"document"`
+	}
+
+	r := routix.New()
+	r.POST("/upload", func(c *routix.Context) error {
+		var u upload
+		if err := c.Bind(&u); err != nil {
+			return err
+		}
+		if u.Document == nil {
+			return c.JSON(400, "missing file")
+		}
+		f, err := u.Document.Open()
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		content, _ := io.ReadAll(f)
+		return c.JSON(200, map[string]any{
+			"title": u.Title, "pages": u.Pages,
+			"file": u.Document.Filename, "content": string(content),
+		})
+	})
+
+	req := httptest.NewRequest("POST", "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	want := `{"content":"hello","file":"report.txt","pages":3,"title":"report"}`
+	if w.Code != 200 || strings.TrimSpace(w.Body.String()) != want {
+		t.Errorf("expected %s, got %d %s", want, w.Code, w.Body.String())
+	}
+}
+
+type fakeHubConn struct {
+	messages chan string
+}
+
+func (c *fakeHubConn) WriteMessage(data []byte) error {
+	c.messages <- string(data)
+	return nil
+}
+
+func TestHubBroadcast(t *testing.T) {
+	hub := routix.NewHub(4)
+	a := &fakeHubConn{messages: make(chan string, 4)}
+	b := &fakeHubConn{messages: make(chan string, 4)}
+	hub.Register(a)
+	hub.Register(b)
+	if hub.Len() != 2 {
+		t.Fatalf("expected 2 connections, got %d", hub.Len())
+	}
+
+	hub.Broadcast([]byte("hello"))
+	for _, conn := range []*fakeHubConn{a, b} {
+		select {
+		case msg := <-conn.messages:
+			if msg != "hello" {
+				t.Errorf("expected hello, got %q", msg)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("broadcast did not reach connection")
+		}
+	}
+
+	hub.Unregister(a)
+	if hub.Len() != 1 {
+		t.Errorf("expected 1 connection after unregister, got %d", hub.Len())
+	}
+}
+
+// gatedHubConn blocks each write until released, then fails the first one.
+type gatedHubConn struct {
+	release  chan struct{}
+	messages chan string
+	writes   int
+}
+
+func (c *gatedHubConn) WriteMessage(data []byte) error {
+	<-c.release
+	c.writes++
+	if c.writes == 1 {
+		return errors.New("connection reset")
+	}
+	c.messages <- string(data)
+	return nil
+}
+
+func TestHubReregister(t *testing.T) {
+	hub := routix.NewHub(4)
+	conn := &gatedHubConn{release: make(chan struct{}, 4), messages: make(chan string, 4)}
+	hub.Register(conn)
+	hub.Broadcast([]byte("stale-1")) // the old writer blocks writing this
+	hub.Broadcast([]byte("stale-2")) // and this stays queued
+	time.Sleep(10 * time.Millisecond)
+
+	hub.Unregister(conn)
+	hub.Register(conn)
+	conn.release <- struct{}{} // the stale write fails
+	time.Sleep(10 * time.Millisecond)
+	if hub.Len() != 1 {
+		t.Fatalf("stale writer's failure unregistered the new registration")
+	}
+
+	hub.Broadcast([]byte("fresh"))
+	conn.release <- struct{}{}
+	select {
+	case msg := <-conn.messages:
+		if msg != "fresh" {
+			t.Errorf("expected fresh, got %q; queued messages survived unregister", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("new registration received nothing")
+	}
+}
+
+func TestLongPoll(t *testing.T) {
+	ready := make(chan string, 1)
+	r := routix.New()
+	r.GET("/events", routix.LongPoll(time.Second, func(ctx context.Context) (interface{}, bool) {
+		select {
+		case msg := <-ready:
+			return map[string]string{"event": msg}, true
+		case <-ctx.Done():
+			return nil, false
+		}
+	}))
+	r.GET("/idle", routix.LongPoll(20*time.Millisecond, func(ctx context.Context) (interface{}, bool) {
+		return nil, false
+	}))
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		ready <- "done"
+	}()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/events", ""))
+	if w.Code != 200 || !strings.Contains(w.Body.String(), `"data":{"event":"done"}`) {
+		t.Errorf("expected event data, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/idle", ""))
+	if w.Code != 204 || w.Body.Len() != 0 {
+		t.Errorf("expected 204 on timeout, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestResponseFlushes(t *testing.T) {
+	r := routix.New()
+	r.GET("/stream", func(c *routix.Context) error {
+		c.SetHeader("Content-Type", "text/event-stream")
+		fmt.Fprint(c.Response, "event: progress\ndata: 50\n\n")
+		return http.NewResponseController(c.Response).Flush()
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/stream", ""))
+	if !w.Flushed || !strings.Contains(w.Body.String(), "event: progress") {
+		t.Errorf("expected a flushed event stream, got flushed=%v %q", w.Flushed, w.Body.String())
+	}
+}
+
+func TestRouterEvents(t *testing.T) {
+	r := routix.New()
+	received := make(chan interface{}, 2)
+	block := make(chan struct{})
+	r.On("user.created", func(payload interface{}) {
+		received <- payload
+	})
+	r.On("user.created", func(payload interface{}) {
+		<-block
+	})
+	defer close(block)
+
+	r.POST("/users", func(c *routix.Context) error {
+		c.Emit("user.created", "u1")
+		return c.Created(map[string]string{"id": "u1"})
+	})
+
+	done := make(chan struct{})
+	go func() {
+		r.ServeHTTP(httptest.NewRecorder(), newRequest("POST", "/users", ""))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Emit blocked on a slow subscriber")
+	}
+
+	select {
+	case payload := <-received:
+		if payload != "u1" {
+			t.Errorf("expected payload u1, got %v", payload)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscriber did not receive the event")
+	}
+}
+
+func TestBindUriAndParam(t *testing.T) {
+	type userPath struct {
+		ID   int    `uri:"id"`
+		Slug string `uri:"slug"`
+	}
+
+	r := routix.New()
+	r.GET("/users/:id/:slug", func(c *routix.Context) error {
+		var p userPath
+		if err := c.BindUri(&p); err != nil {
+			return err
+		}
+		if strconv.Itoa(p.ID) != c.Params["id"] || p.Slug != c.Param("slug") {
+			t.Errorf("BindUri %+v disagrees with params %v", p, c.Params)
+		}
+		if c.Param("missing") != "" {
+			t.Errorf("expected empty string for a missing param")
+		}
+		return c.JSON(200, p)
+	})
+	r.GET("/bad/:id", func(c *routix.Context) error {
+		var p userPath
+		return c.BindUri(&p)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/users/42/ada", ""))
+	if w.Code != 200 || strings.TrimSpace(w.Body.String()) != `{"ID":42,"Slug":"ada"}` {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/bad/abc", ""))
+	if w.Code != 400 {
+		t.Errorf("expected 400 for a non-numeric id, got %d", w.Code)
+	}
+}
+
+func TestMaxParams(t *testing.T) {
+	r := routix.New().MaxParams(3)
+	var got map[string]string
+	r.GET("/:a/:b/:c/:d/:e", func(c *routix.Context) error {
+		got = make(map[string]string, len(c.Params))
+		for k, v := range c.Params {
+			got[k] = v
+		}
+		return c.NoContent()
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/1/2/3/4/5", ""))
+	if w.Code != 204 {
+		t.Fatalf("expected the route to match, got %d", w.Code)
+	}
+	if len(got) != 3 || got["a"] != "1" || got["b"] != "2" || got["c"] != "3" {
+		t.Errorf("expected the first 3 params only, got %v", got)
+	}
+
+	r = routix.New()
+	r.GET("/:a/:b/:c/:d/:e", func(c *routix.Context) error {
+		if len(c.Params) != 5 {
+			t.Errorf("expected all 5 params by default, got %v", c.Params)
+		}
+		return nil
+	})
+	r.ServeHTTP(httptest.NewRecorder(), newRequest("GET", "/1/2/3/4/5", ""))
+}
+
+func TestPropagateHeaders(t *testing.T) {
+	r := routix.New()
+	r.GET("/proxy", func(c *routix.Context) error {
+		out, _ := http.NewRequest("GET", "http://downstream/items", nil)
+		c.PropagateHeaders(out)
+		if got := out.Header.Get("X-Request-ID"); got != "req-1" {
+			t.Errorf("expected request id req-1, got %q", got)
+		}
+		if got := out.Header.Get("traceparent"); got != "00-abc-def-01" {
+			t.Errorf("expected traceparent to be copied, got %q", got)
+		}
+		if out.Header.Get("Authorization") != "" {
+			t.Errorf("expected Authorization not to be copied by default")
+		}
+
+		out, _ = http.NewRequest("GET", "http://downstream/items", nil)
+		c.PropagateHeaders(out, "Authorization")
+		if out.Header.Get("Authorization") != "Bearer t" || out.Header.Get("X-Request-ID") != "" {
+			t.Errorf("expected only Authorization, got %v", out.Header)
+		}
+		return nil
+	})
+
+	req := newRequest("GET", "/proxy", "")
+	req.Header.Set("X-Request-ID", "req-1")
+	req.Header.Set("traceparent", "00-abc-def-01")
+	req.Header.Set("Authorization", "Bearer t")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestRawSuccess(t *testing.T) {
+	r := routix.New()
+	r.GET("/wrapped", func(c *routix.Context) error {
+		return c.Success(map[string]bool{"ok": true})
+	})
+	r.POST("/webhook", func(c *routix.Context) error {
+		return c.Raw().Success(map[string]bool{"ok": true})
+	})
+	r.POST("/hook", func(c *routix.Context) error {
+		return c.Created(map[string]bool{"ok": true})
+	}).Raw()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/wrapped", ""))
+	if !strings.Contains(w.Body.String(), `"status":"success"`) {
+		t.Errorf("expected an envelope, got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("POST", "/webhook", ""))
+	if w.Code != 200 || strings.TrimSpace(w.Body.String()) != `{"ok":true}` {
+		t.Errorf("expected a bare body, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("POST", "/hook", ""))
+	if w.Code != 201 || strings.TrimSpace(w.Body.String()) != `{"ok":true}` {
+		t.Errorf("expected a bare 201 body, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestStatusCodeInMiddleware(t *testing.T) {
+	var seen []int
+	record := func(next routix.Handler) routix.Handler {
+		return func(c *routix.Context) error {
+			err := next(c)
+			seen = append(seen, c.StatusCode())
+			return err
+		}
+	}
+
+	r := routix.New()
+	r.Use(record, record)
+	r.POST("/items", func(c *routix.Context) error {
+		return c.JSON(http.StatusCreated, "ok")
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), newRequest("POST", "/items", ""))
+	if len(seen) != 2 || seen[0] != 201 || seen[1] != 201 {
+		t.Errorf("expected both middleware to see 201, got %v", seen)
+	}
+}
+
+func TestCacheSkipsCancelledRecompute(t *testing.T) {
+	calls := 0
+	r := routix.New()
+	r.Use(routix.Cache(time.Minute))
+	r.GET("/report", func(c *routix.Context) error {
+		calls++
+		if cancel, ok := c.RequestContext().Value(cancelKey{}).(context.CancelFunc); ok {
+			cancel()
+		}
+		return c.JSON(200, map[string]int{"calls": calls})
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = context.WithValue(ctx, cancelKey{}, cancel)
+	r.ServeHTTP(httptest.NewRecorder(), newRequest("GET", "/report", "").WithContext(ctx))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/report", ""))
+	if calls != 2 || !strings.Contains(w.Body.String(), `"calls":2`) {
+		t.Errorf("expected the cancelled response not to be cached, calls=%d body=%s", calls, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/report", ""))
+	if calls != 2 {
+		t.Errorf("expected the completed response to be cached, calls=%d", calls)
+	}
+}
+
+type cancelKey struct{}
+
+func TestRouterCacheVariesByEncoding(t *testing.T) {
+	r := routix.New()
+	r.GET("/page", func(c *routix.Context) error {
+		return c.String(200, "fresh")
+	})
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte("cached"))
+	zw.Close()
+
+	gzipReq := newRequest("GET", "/page", "")
+	gzipReq.Header.Set("Accept-Encoding", "gzip")
+	r.CacheResponseFor(gzipReq, gz.Bytes(), http.Header{"Content-Encoding": {"gzip"}}, 200, time.Minute)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/page", ""))
+	if w.Header().Get("Content-Encoding") != "" || w.Body.String() != "fresh" {
+		t.Errorf("plain client got the gzip variant: %q %q", w.Header().Get("Content-Encoding"), w.Body.String())
+	}
+
+	r.CacheResponseFor(newRequest("GET", "/page", ""), []byte("cached plain"), http.Header{}, 200, time.Minute)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/page", ""))
+	if w.Body.String() != "cached plain" {
+		t.Errorf("expected the plain variant, got %q", w.Body.String())
+	}
+
+	req := newRequest("GET", "/page", "")
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Header().Get("Content-Encoding") != "gzip" || !bytes.Equal(w.Body.Bytes(), gz.Bytes()) {
+		t.Errorf("expected the gzip variant, got %q", w.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestErrorStackOnlyInDevModeFor5xx(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	routes := func(r *routix.Router) *routix.Router {
+		r.GET("/bad", func(c *routix.Context) error {
+			return routix.BadRequest("bad input", nil)
+		})
+		r.GET("/boom", func(c *routix.Context) error {
+			return routix.InternalServerError("boom", nil)
+		})
+		return r
+	}
+	hasStack := func(r *routix.Router, path string) bool {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("GET", path, ""))
+		return strings.Contains(w.Body.String(), `"stack"`)
+	}
+
+	prod := routes(routix.New())
+	dev := routes(routix.New().EnableDevMode())
+	if hasStack(prod, "/bad") || hasStack(dev, "/bad") {
+		t.Error("client errors must never include a stack trace")
+	}
+	if hasStack(prod, "/boom") {
+		t.Error("server errors must not include a stack trace outside dev mode")
+	}
+	if !hasStack(dev, "/boom") {
+		t.Error("expected a stack trace for server errors in dev mode")
+	}
+	if !strings.Contains(logs.String(), "routix: GET /boom: boom") {
+		t.Errorf("expected the stack to be logged, got %q", logs.String())
+	}
+}
+
+func TestParamIntBaseHexUint(t *testing.T) {
+	r := routix.New()
+	r.GET("/objects/:id", func(c *routix.Context) error {
+		id, err := c.ParamHex("id")
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, id)
+	})
+	r.GET("/small/:n", func(c *routix.Context) error {
+		n, err := c.ParamIntBase("n", 10, 8)
+		if err != nil {
+			if !errors.Is(err, strconv.ErrRange) {
+				t.Errorf("expected a range error, got %v", err)
+			}
+			return err
+		}
+		return c.JSON(200, n)
+	})
+	r.GET("/count/:n", func(c *routix.Context) error {
+		n, err := c.ParamUint("n")
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, n)
+	})
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/objects/ff", 200, "255"},
+		{"/objects/DEADBEEF", 200, "3735928559"},
+		{"/objects/zz", 400, `invalid path parameter id="zz"`},
+		{"/objects/1ffffffffffffffff", 400, "value out of range"},
+		{"/small/-128", 200, "-128"},
+		{"/small/300", 400, "value out of range"},
+		{"/count/18446744073709551615", 200, "18446744073709551615"},
+		{"/count/-1", 400, "invalid syntax"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("GET", tt.path, ""))
+		if w.Code != tt.code || !strings.Contains(w.Body.String(), tt.body) {
+			t.Errorf("%s: expected %d %q, got %d %s", tt.path, tt.code, tt.body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestValidationErrorsUseJSONNames(t *testing.T) {
+	type signup struct {
+		Email    string `json:"email,omitempty" validate:"required,email"`
+		Nickname string `validate:"min=3"`
+	}
+
+	v := routix.NewValidator()
+	if v.Validate(&signup{Email: "nope", Nickname: "al"}) {
+		t.Fatal("expected validation to fail")
+	}
+	if errs := v.Errors(); len(errs) != 2 || errs[0].Field != "email" || errs[1].Field != "Nickname" {
+		t.Errorf("expected json tag names, got %+v", errs)
+	}
+
+	r := routix.New()
+	r.POST("/signup", routix.Validate(&signup{})(func(c *routix.Context) error {
+		return c.NoContent()
+	}))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("POST", "/signup", `{"email":"nope","Nickname":"alice"}`))
+	if w.Code != 400 || !strings.Contains(w.Body.String(), "email: must be a valid email address") {
+		t.Errorf("expected the json name in the error, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRawBodyForSignatures(t *testing.T) {
+	secret := []byte("whsec")
+	payload := `{"type":"invoice.paid","id":"evt_1"}`
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	r := routix.New()
+	r.POST("/webhook", func(c *routix.Context) error {
+		raw, err := c.RawBody()
+		if err != nil {
+			return err
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(raw)
+		if !hmac.Equal([]byte(hex.EncodeToString(mac.Sum(nil))), []byte(c.GetHeader("X-Signature"))) {
+			return c.Unauthorized("bad signature")
+		}
+
+		var event struct {
+			Type string `json:"type"`
+			ID   string `json:"id"`
+		}
+		if err := c.Bind(&event); err != nil {
+			return err
+		}
+		return c.JSON(200, event)
+	})
+
+	req := newRequest("POST", "/webhook", payload)
+	req.Header.Set("X-Signature", signature)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 200 || !strings.Contains(w.Body.String(), `"id":"evt_1"`) {
+		t.Errorf("expected verified and bound event, got %d %s", w.Code, w.Body.String())
+	}
+
+	req = newRequest("POST", "/webhook", payload)
+	req.Header.Set("X-Signature", "forged")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 401 {
+		t.Errorf("expected 401 for a bad signature, got %d", w.Code)
+	}
+}
+
+func TestOnReload(t *testing.T) {
+	var calls []string
+	r := routix.New().
+		OnReload(func() { calls = append(calls, "config") }).
+		OnReload(func() { calls = append(calls, "logs") })
+
+	r.Reload()
+	r.Reload()
+	if strings.Join(calls, ",") != "config,logs,config,logs" {
+		t.Errorf("expected callbacks in order on each reload, got %v", calls)
+	}
+}
+
+func TestQuietSuppressesBanner(t *testing.T) {
+	// An invalid port makes Start return right after printing the banner.
+	const addr = "127.0.0.1:-1"
+
+	out := captureStdout(t, func() { routix.NewAPI().Start(addr) })
+	if !strings.Contains(out, "Routix") {
+		t.Fatalf("expected the banner by default, got %q", out)
+	}
+
+	out = captureStdout(t, func() { routix.NewAPI().Dev().Quiet().Start(addr) })
+	if out != "" {
+		t.Errorf("expected no output in quiet mode, got %q", out)
+	}
+	routix.DevMode = false
+
+	t.Setenv("ROUTIX_NO_BANNER", "1")
+	out = captureStdout(t, func() { routix.New().Start(addr) })
+	if out != "" {
+		t.Errorf("expected ROUTIX_NO_BANNER to suppress the banner, got %q", out)
+	}
+}
+
+func TestWrapStdlibHandlersAndMiddleware(t *testing.T) {
+	type ctxKey struct{}
+	stdMiddleware := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("X-Std", "yes")
+			ctx := context.WithValue(req.Context(), ctxKey{}, "from-std")
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	}
+	deny := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			http.Error(w, "denied", http.StatusForbidden)
+		})
+	}
+
+	r := routix.New()
+	r.Use(routix.WrapMiddleware(stdMiddleware))
+	r.GET("/std", routix.WrapHandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "std handler %v", req.Context().Value(ctxKey{}))
+	}))
+	r.GET("/native", func(c *routix.Context) error {
+		return c.String(200, "native %v", c.RequestContext().Value(ctxKey{}))
+	})
+	r.GET("/denied", routix.WrapMiddleware(deny)(func(c *routix.Context) error {
+		t.Error("handler behind a rejecting middleware must not run")
+		return nil
+	}))
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/std", 200, "std handler from-std"},
+		{"/native", 200, "native from-std"},
+		{"/denied", 403, "denied"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("GET", tt.path, ""))
+		if w.Code != tt.code || !strings.Contains(w.Body.String(), tt.body) || w.Header().Get("X-Std") != "yes" {
+			t.Errorf("%s: expected %d %q, got %d %q (X-Std=%q)", tt.path, tt.code, tt.body, w.Code, w.Body.String(), w.Header().Get("X-Std"))
+		}
+	}
+}
+
+func TestPooledContextsDoNotLeakState(t *testing.T) {
+	routix.DevMode = true // panics if a pooled Context comes back dirty
+	defer func() { routix.DevMode = false }()
+
+	r := routix.New()
+	r.Use(routix.Cache(time.Minute))
+	r.POST("/login", func(c *routix.Context) error {
+		c.Set("user", "alice")
+		c.RawBody()
+		return c.Raw().Success(map[string]string{"user": "alice"})
+	})
+	r.GET("/me", func(c *routix.Context) error {
+		if user, ok := c.Get("user"); ok {
+			t.Errorf("value leaked from an earlier request: %v", user)
+		}
+		return c.Success(nil)
+	})
+
+	for i := 0; i < 50; i++ {
+		r.ServeHTTP(httptest.NewRecorder(), newRequest("POST", "/login", `{"name":"alice"}`))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("GET", fmt.Sprintf("/me?i=%d", i), ""))
+		if !strings.Contains(w.Body.String(), `"status":"success"`) {
+			t.Fatalf("raw mode leaked into a later request: %s", w.Body.String())
+		}
+	}
+}
+
+func TestCacheKeepsContextValues(t *testing.T) {
+	r := routix.New()
+	r.Use(func(next routix.Handler) routix.Handler {
+		return func(c *routix.Context) error {
+			c.Set("tenant", "acme")
+			return next(c)
+		}
+	}, routix.Cache(time.Minute))
+	r.GET("/tenant", func(c *routix.Context) error {
+		return c.String(200, "%v", c.MustGet("tenant"))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/tenant", ""))
+	if w.Body.String() != "acme" {
+		t.Errorf("expected values set before Cache to reach the handler, got %q", w.Body.String())
+	}
+}
+
+func TestAutoParseBody(t *testing.T) {
+	handler := func(c *routix.Context) error {
+		return c.JSON(200, c.Body)
+	}
+
+	w := httptest.NewRecorder()
+	lazy := routix.New()
+	lazy.POST("/items", handler)
+	lazy.ServeHTTP(w, newRequest("POST", "/items", `{"name":"ada"}`))
+	if strings.TrimSpace(w.Body.String()) != "null" {
+		t.Errorf("expected the body not to be parsed by default, got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	eager := routix.New().AutoParseBody(true)
+	eager.POST("/items", handler)
+	eager.ServeHTTP(w, newRequest("POST", "/items", `{"name":"ada"}`))
+	if strings.TrimSpace(w.Body.String()) != `{"name":"ada"}` {
+		t.Errorf("expected AutoParseBody to populate c.Body, got %s", w.Body.String())
+	}
+}
+
+func BenchmarkUnusedJSONBody(b *testing.B) {
+	body := `{"items":[` + strings.Repeat(`{"id":1,"tags":["a","b"]},`, 200) + `{"id":2}]}`
+	for _, auto := range []bool{false, true} {
+		r := routix.New().AutoParseBody(auto)
+		r.POST("/ingest", func(c *routix.Context) error {
+			return c.NoContent()
+		})
+		b.Run(fmt.Sprintf("auto=%v", auto), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				r.ServeHTTP(httptest.NewRecorder(), newRequest("POST", "/ingest", body))
+			}
+		})
+	}
+}
+
+func TestServeFileAndContent(t *testing.T) {
+	dir := t.TempDir()
+	robots := filepath.Join(dir, "robots.txt")
+	if err := os.WriteFile(robots, []byte("User-agent: *\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := routix.New()
+	r.ServeFile("/robots.txt", robots)
+	r.ServeContent("/config.json", time.Now(), strings.NewReader(`{"theme":"dark"}`))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/robots.txt", ""))
+	if w.Code != 200 || w.Body.String() != "User-agent: *\n" || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("unexpected file response %d %q %q", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/config.json", ""))
+	if w.Code != 200 || w.Body.String() != `{"theme":"dark"}` || w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected content response %d %q %q", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+
+	req := newRequest("GET", "/config.json", "")
+	req.Header.Set("Range", "bytes=2-6")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusPartialContent || w.Body.String() != `theme` {
+		t.Errorf("expected a partial response, got %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("HEAD", "/robots.txt", ""))
+	if w.Code != 200 || w.Body.Len() != 0 {
+		t.Errorf("expected an empty HEAD response, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestPanicRecoveryControls(t *testing.T) {
+	panics := func(r *routix.Router) (recovered interface{}) {
+		defer func() { recovered = recover() }()
+		r.ServeHTTP(httptest.NewRecorder(), newRequest("GET", "/boom", ""))
+		return nil
+	}
+	boom := func(c *routix.Context) error { panic("boom") }
+
+	r := routix.New().Use(routix.Recovery())
+	r.GET("/boom", boom)
+	if v := panics(r); v != nil {
+		t.Errorf("expected Recovery to recover, got panic %v", v)
+	}
+
+	r.DisableRecovery()
+	if v := panics(r); v != "boom" {
+		t.Errorf("expected the panic to propagate with recovery disabled, got %v", v)
+	}
+
+	r = routix.New().Use(routix.RecoverToError())
+	r.GET("/boom", boom)
+	routix.RecoverPanics = false
+	v := panics(r)
+	routix.RecoverPanics = true
+	if v != "boom" {
+		t.Errorf("expected RecoverPanics=false to let the panic through, got %v", v)
+	}
+
+	var got interface{}
+	r = routix.New().PanicHandler(func(c *routix.Context, v interface{}) {
+		got = v
+		c.JSON(http.StatusServiceUnavailable, "try later")
+	})
+	r.GET("/boom", boom)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/boom", ""))
+	if got != "boom" || w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected PanicHandler to handle the panic, got %v and %d", got, w.Code)
+	}
+}
+
+func TestBindAndValidate(t *testing.T) {
+	type signup struct {
+		Email string `json:"email" validate:"required,email"`
+		Age   int    `json:"age" validate:"min=18"`
+	}
+
+	r := routix.New()
+	r.POST("/signup", func(c *routix.Context) error {
+		var in signup
+		if errs, ok := c.BindAndValidate(&in); !ok {
+			return c.JSON(422, errs)
+		}
+		return c.NoContent()
+	})
+
+	tests := []struct {
+		body string
+		code int
+		want string
+	}{
+		{`{"email":"a@example.com","age":30}`, 204, ""},
+		{`{"email":"nope","age":12}`, 422, `{"age":"value must be at least 18","email":"must be a valid email address"}`},
+		{`{"age":30}`, 422, `{"email":"field is required"}`},
+		{`{"email":`, 422, `"body":`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("POST", "/signup", tt.body))
+		if w.Code != tt.code || !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("%s: expected %d %s, got %d %s", tt.body, tt.code, tt.want, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestContextOnce(t *testing.T) {
+	calls := 0
+	decode := func(c *routix.Context) (any, error) {
+		return c.Once("claims", func() (any, error) {
+			calls++
+			return map[string]string{"sub": "u1"}, nil
+		})
+	}
+
+	r := routix.New()
+	r.Use(func(next routix.Handler) routix.Handler {
+		return func(c *routix.Context) error {
+			if _, err := decode(c); err != nil {
+				return err
+			}
+			return next(c)
+		}
+	})
+	r.GET("/me", func(c *routix.Context) error {
+		claims, err := decode(c)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, claims)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/me", ""))
+	if calls != 1 || !strings.Contains(w.Body.String(), `"sub":"u1"`) {
+		t.Errorf("expected one computation per request, got %d calls and %s", calls, w.Body.String())
+	}
+
+	r.ServeHTTP(httptest.NewRecorder(), newRequest("GET", "/me", ""))
+	if calls != 2 {
+		t.Errorf("expected a fresh computation for a new request, got %d calls", calls)
+	}
+}
+
+func TestHealthReportsVersionAndUptime(t *testing.T) {
+	r := routix.NewAPI().Version("1.4.2", "2026-10-01T12:00:00Z").Health("/healthz").Build()
+
+	uptime := func() float64 {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("GET", "/healthz", ""))
+		var body struct {
+			Data struct {
+				Status    string  `json:"status"`
+				Uptime    float64 `json:"uptime"`
+				Version   string  `json:"version"`
+				BuildTime string  `json:"build_time"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if body.Data.Status != "healthy" || body.Data.Version != "1.4.2" || body.Data.BuildTime != "2026-10-01T12:00:00Z" {
+			t.Errorf("unexpected health body %s", w.Body.String())
+		}
+		return body.Data.Uptime
+	}
+
+	first := uptime()
+	time.Sleep(5 * time.Millisecond)
+	if second := uptime(); second <= first || first <= 0 {
+		t.Errorf("expected uptime to increase, got %v then %v", first, second)
+	}
+}
+
+func TestRequestIDHeaders(t *testing.T) {
+	r := routix.New().Use(routix.RequestIDWithOptions(routix.RequestIDOptions{
+		Inbound:  []string{"X-Request-ID", "X-Correlation-ID"},
+		Outbound: "X-Correlation-ID",
+		Generate: func() string { return "generated" },
+	}))
+	r.GET("/", func(c *routix.Context) error {
+		out, _ := http.NewRequest("GET", "http://downstream/", nil)
+		c.PropagateHeaders(out)
+		return c.String(200, "%s", out.Header.Get("X-Request-ID"))
+	})
+
+	req := newRequest("GET", "/", "")
+	req.Header.Set("X-Correlation-ID", "corr-7")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Header().Get("X-Correlation-ID") != "corr-7" || w.Body.String() != "corr-7" {
+		t.Errorf("expected the inbound correlation id, got %q / %q", w.Header().Get("X-Correlation-ID"), w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/", ""))
+	if w.Header().Get("X-Correlation-ID") != "generated" {
+		t.Errorf("expected a generated id, got %q", w.Header().Get("X-Correlation-ID"))
+	}
+
+	r = routix.New().Use(routix.RequestID())
+	r.GET("/", func(c *routix.Context) error { return nil })
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/", ""))
+	if len(w.Header().Get("X-Request-ID")) != 32 {
+		t.Errorf("expected a default generated id, got %q", w.Header().Get("X-Request-ID"))
+	}
+}
+
+func TestRouteCache(t *testing.T) {
+	r := routix.New()
+	calls := 0
+	r.GET("/about", func(c *routix.Context) error {
+		calls++
+		return c.JSON(200, map[string]int{"calls": calls})
+	}).Cache(time.Hour)
+	r.GET("/live", func(c *routix.Context) error {
+		calls++
+		return c.JSON(200, map[string]int{"calls": calls})
+	})
+
+	var bodies []string
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("GET", "/about", ""))
+		if w.Code != 200 {
+			t.Fatalf("status = %d", w.Code)
+		}
+		bodies = append(bodies, w.Body.String())
+	}
+	if calls != 1 || bodies[0] != bodies[1] {
+		t.Fatalf("second request was not served from cache: calls=%d bodies=%q", calls, bodies)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/about?lang=fr", ""))
+	if calls != 2 {
+		t.Errorf("different URL should miss the cache, calls=%d", calls)
+	}
+	for i := 0; i < 2; i++ {
+		r.ServeHTTP(httptest.NewRecorder(), newRequest("GET", "/live", ""))
+	}
+	if calls != 4 {
+		t.Errorf("uncached route should run every time, calls=%d", calls)
+	}
+
+	// Query order doesn't matter; a full cache drops its oldest entry.
+	defer func(n int) { routix.CacheMaxEntries = n }(routix.CacheMaxEntries)
+	routix.CacheMaxEntries = 2
+	calls = 0
+	r.GET("/list", func(c *routix.Context) error {
+		calls++
+		return c.JSON(200, calls)
+	}).Cache(time.Hour)
+	for _, url := range []string{"/list?a=1&b=2", "/list?b=2&a=1", "/list?page=2", "/list?page=3", "/list?page=3", "/list?a=1&b=2"} {
+		r.ServeHTTP(httptest.NewRecorder(), newRequest("GET", url, ""))
+	}
+	if calls != 4 {
+		t.Errorf("expected 4 misses (3 keys, then the evicted one), calls=%d", calls)
+	}
+}
+
+func TestFormBody(t *testing.T) {
+	type login struct {
+		User     string `form:"user"`
+		Remember bool   `form:"remember"`
+	}
+	r := routix.New()
+	r.POST("/login", func(c *routix.Context) error {
+		form, err := c.ParseForm()
+		if err != nil {
+			return err
+		}
+		again, _ := c.ParseForm()
+		if again.Get("user") != form.Get("user") {
+			t.Errorf("second ParseForm = %v", again)
+		}
+		var in login
+		if err := c.Bind(&in); err != nil {
+			return err
+		}
+		return c.JSON(200, map[string]any{
+			"form":     form.Get("user"),
+			"tags":     form["tag"],
+			"bound":    in,
+			"body_map": c.BodyMap()["user"],
+		})
+	})
+
+	req := httptest.NewRequest("POST", "/login", strings.NewReader("user=ada&remember=true&tag=a&tag=b"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	var got struct {
+		Form    string   `json:"form"`
+		Tags    []string `json:"tags"`
+		Bound   login    `json:"bound"`
+		BodyMap string   `json:"body_map"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &got)
+	if got.Form != "ada" || len(got.Tags) != 2 || got.Bound.User != "ada" || !got.Bound.Remember || got.BodyMap != "ada" {
+		t.Errorf("unexpected result: %+v", got)
+	}
+}
+
+func TestRouteRateLimit(t *testing.T) {
+	r := routix.New()
+	r.POST("/login", func(c *routix.Context) error {
+		return c.JSON(200, nil)
+	}).RateLimit(5, time.Minute)
+	r.GET("/items", func(c *routix.Context) error {
+		return c.JSON(200, nil)
+	})
+
+	for i := 0; i < 5; i++ {
+		req := newRequest("POST", "/login", "")
+		req.RemoteAddr = fmt.Sprintf("10.0.0.1:%d", 40000+i) // new connection each time
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != 200 {
+			t.Fatalf("attempt %d: status = %d", i+1, w.Code)
+		}
+	}
+
+	req := newRequest("POST", "/login", "")
+	req.RemoteAddr = "10.0.0.1:50000"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code == 200 {
+		t.Fatal("6th login attempt should be rejected")
+	}
+
+	req = newRequest("POST", "/login", "")
+	req.RemoteAddr = "10.0.0.2:50000"
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 200 {
+		t.Errorf("other client should not be limited, got %d", w.Code)
+	}
+
+	for i := 0; i < 10; i++ {
+		req := newRequest("GET", "/items", "")
+		req.RemoteAddr = "10.0.0.1:50000"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != 200 {
+			t.Fatalf("unlimited route request %d: status = %d", i+1, w.Code)
+		}
+	}
+}
+
+func TestRateLimitBehindTrustedPlatform(t *testing.T) {
+	r := routix.New().TrustedPlatform(routix.PlatformCloudflare)
+	r.POST("/login", func(c *routix.Context) error {
+		return c.JSON(200, nil)
+	}).RateLimit(1, time.Minute)
+
+	login := func(client string) int {
+		req := newRequest("POST", "/login", "")
+		req.RemoteAddr = "172.16.0.1:443" // every request arrives from the proxy
+		req.Header.Set("CF-Connecting-IP", client)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := login("203.0.113.1"); code != 200 {
+		t.Fatalf("first client: status %d", code)
+	}
+	if code := login("203.0.113.1"); code == 200 {
+		t.Error("first client's second attempt should be limited")
+	}
+	if code := login("203.0.113.2"); code != 200 {
+		t.Errorf("second client behind the same proxy was limited: %d", code)
+	}
+}
+
+func TestAbortRedirect(t *testing.T) {
+	r := routix.New()
+	ran := false
+	protected := func(c *routix.Context) error {
+		ran = true
+		return c.JSON(200, nil)
+	}
+	// A middleware that keeps going after aborting still skips the handler.
+	r.Use(func(next routix.Handler) routix.Handler {
+		return func(c *routix.Context) error {
+			if c.Request.URL.Path == "/account" && c.Request.Header.Get("Cookie") == "" {
+				c.AbortRedirect(302, "/login")
+			}
+			return next(c)
+		}
+	})
+	r.GET("/account", protected)
+	r.Group("/admin").Use(routix.AuthWithOptions(func(tok string) bool { return tok == "ok" },
+		routix.AuthOptions{RedirectURL: "/login"})).GET("/panel", protected)
+
+	for _, path := range []string{"/account", "/admin/panel"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("GET", path, ""))
+		if w.Code != 302 || w.Header().Get("Location") != "/login" {
+			t.Errorf("%s: got %d Location %q, want 302 to /login", path, w.Code, w.Header().Get("Location"))
+		}
+		if ran {
+			t.Errorf("%s: protected handler ran", path)
+		}
+	}
+
+	req := newRequest("GET", "/admin/panel", "")
+	req.Header.Set("Authorization", "Bearer ok")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 200 || !ran {
+		t.Errorf("authenticated request: status %d, ran %v", w.Code, ran)
+	}
+}
+
+func BenchmarkValidateSameType(b *testing.B) {
+	type signup struct {
+		Name  string `json:"name" validate:"required,min=2,max=50"`
+		Email string `json:"email" validate:"required,email"`
+		Age   int    `json:"age" validate:"min=18,max=130"`
+		Role  string `json:"role" validate:"enum=admin|user"`
+	}
+	in := &signup{Name: "Ada", Email: "ada@example.com", Age: 36, Role: "admin"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if !routix.NewValidator().Validate(in) {
+			b.Fatal("expected valid input")
+		}
+	}
+}
+
+func TestParseConstraintsCached(t *testing.T) {
+	tag := "required,regex=^[a-z]+-[0-9]+$"
+	first, err := routix.ParseConstraints(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := routix.ParseConstraints(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 2 || &first[0] != &second[0] {
+		t.Fatalf("expected the cached constraints back, got %v and %v", first, second)
+	}
+	if first[1].(routix.RegexConstraint).Pattern != second[1].(routix.RegexConstraint).Pattern {
+		t.Error("expected the compiled regex to be reused")
+	}
+	if err := second[1].Validate("ticket-42"); err != nil {
+		t.Errorf("cached regex constraint rejected a match: %v", err)
+	}
+
+	// Appending to a result must not leak into the cache.
+	_ = append(first, routix.AlphaConstraint{})
+	if again, _ := routix.ParseConstraints(tag); len(again) != 2 {
+		t.Errorf("cache modified by append: %v", again)
+	}
+	if _, err := routix.ParseConstraints("bogus"); err == nil {
+		t.Error("expected an error for an unknown constraint")
+	}
+}
+
+func BenchmarkParseConstraints(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := routix.ParseConstraints("required,min=3,max=64,regex=^[a-z0-9-]+$,enum=a|b|c"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestJSONEncodeFailureWritesNothing(t *testing.T) {
+	var encodeErr error
+	r := routix.New()
+	r.GET("/bad", func(c *routix.Context) error {
+		encodeErr = c.JSON(200, map[string]any{"ok": true, "ch": make(chan int)})
+		return encodeErr
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/bad", ""))
+	var unsupported *json.UnsupportedTypeError
+	if !errors.As(encodeErr, &unsupported) {
+		t.Fatalf("expected the encoding error to be returned, got %v", encodeErr)
+	}
+	if w.Code != 500 {
+		t.Errorf("status = %d, want 500", w.Code)
+	}
+	if strings.Contains(w.Body.String(), `"ok"`) {
+		t.Errorf("partial JSON reached the client: %q", w.Body.String())
+	}
+}
+
+func TestGroupPathJoining(t *testing.T) {
+	r := routix.New()
+	ok := func(c *routix.Context) error { return c.JSON(200, c.Request.URL.Path) }
+	api := r.Group("/api/")
+	api.GET("/users", ok)
+	api.GET("", ok)
+	api.GET("orders", ok)
+	api.Group("/v2/").GET("//items", ok)
+	r.Group("").GET("/plain", ok)
+
+	var paths []string
+	for _, info := range r.Routes() {
+		paths = append(paths, info.Path)
+	}
+	want := []string{"/api/users", "/api", "/api/orders", "/api/v2/items", "/plain"}
+	if fmt.Sprint(paths) != fmt.Sprint(want) {
+		t.Errorf("registered paths = %v, want %v", paths, want)
+	}
+	for _, p := range want {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("GET", p, ""))
+		if w.Code != 200 {
+			t.Errorf("GET %s: status %d", p, w.Code)
+		}
+	}
+}
+
+func TestRouteBuilderJSONStatus(t *testing.T) {
+	r := routix.New()
+	r.Route("DELETE", "/items/:id").JSONStatus(204, nil)
+	r.Route("PUT", "/items/:id").JSONStatus(202, map[string]string{"status": "queued"})
+	r.Route("PATCH", "/items/:id").Text("patched")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("DELETE", "/items/1", ""))
+	if w.Code != 204 || w.Body.Len() != 0 {
+		t.Errorf("DELETE: got %d with body %q, want empty 204", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("PUT", "/items/1", ""))
+	if w.Code != 202 || !strings.Contains(w.Body.String(), `"queued"`) {
+		t.Errorf("PUT: got %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("PATCH", "/items/1", ""))
+	if w.Code != 200 || w.Body.String() != "patched" {
+		t.Errorf("PATCH: got %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/items/1", ""))
+	if w.Code == 200 || w.Code == 204 {
+		t.Errorf("GET should not match routes registered for other methods, got %d", w.Code)
+	}
+}
+
+func TestEffectiveMiddleware(t *testing.T) {
+	pass := func(next routix.Handler) routix.Handler { return next }
+	ok := func(c *routix.Context) error { return c.JSON(200, nil) }
+
+	r := routix.New()
+	r.UseNamed("requestID", pass).Use(routix.Logger())
+	admin := r.Group("/admin").UseNamed("auth", pass).UseNamed("audit", pass)
+	admin.GET("/users/:id", ok).RateLimit(10, time.Minute).Cache(time.Minute)
+	r.GET("/health", ok)
+
+	got := r.EffectiveMiddleware("GET", "/admin/users/42")
+	want := []string{"requestID", "routix.Logger", "auth", "audit", "routix.Cache", "routix.RateLimit"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("/admin/users/42: got %v, want %v", got, want)
+	}
+	if got := r.EffectiveMiddleware("GET", "/health"); fmt.Sprint(got) != "[requestID routix.Logger]" {
+		t.Errorf("/health: got %v", got)
+	}
+	if got := r.EffectiveMiddleware("POST", "/health"); got != nil {
+		t.Errorf("unmatched method: got %v, want nil", got)
+	}
+	if got := r.EffectiveMiddleware("GET", "/missing"); got != nil {
+		t.Errorf("unmatched path: got %v, want nil", got)
+	}
+	for _, info := range r.Routes() {
+		if info.Path == "/admin/users/:id" && fmt.Sprint(info.Middleware) != fmt.Sprint(want) {
+			t.Errorf("Routes middleware = %v, want %v", info.Middleware, want)
+		}
+	}
+}
+
+func TestValidateSource(t *testing.T) {
+	type search struct {
+		Q     string `query:"q" validate:"required"`
+		Limit int    `query:"limit" default:"10" validate:"min=1,max=50"`
+	}
+	type itemParams struct {
+		ID int `uri:"id" validate:"min=1"`
+	}
+
+	r := routix.New()
+	r.GET("/search", routix.ValidateQuery(&search{})(func(c *routix.Context) error {
+		return c.JSON(200, c.MustGet(routix.ValidatedKey))
+	}))
+	r.GET("/items/:id", routix.ValidateParams(&itemParams{})(func(c *routix.Context) error {
+		return c.JSON(200, c.MustGet(routix.ValidatedKey))
+	}))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/search?q=go", ""))
+	if w.Code != 200 || strings.TrimSpace(w.Body.String()) != `{"Q":"go","Limit":10}` {
+		t.Errorf("valid query: %d %s", w.Code, w.Body.String())
+	}
+
+	for _, url := range []string{"/search", "/search?q=go&limit=500", "/search?q=go&limit=many", "/items/0", "/items/abc"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("GET", url, ""))
+		if w.Code != 422 {
+			t.Errorf("%s: status %d, want 422 (%s)", url, w.Code, w.Body.String())
+		}
+	}
+
+	// A failed request must not leak into the next one.
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/items/7", ""))
+	if w.Code != 200 || strings.TrimSpace(w.Body.String()) != `{"ID":7}` {
+		t.Errorf("valid params: %d %s", w.Code, w.Body.String())
+	}
+
+	type note struct {
+		Text string `json:"text" validate:"required"`
+	}
+	r.POST("/notes", routix.ValidateSource(&note{}, routix.SourceJSON)(func(c *routix.Context) error {
+		return c.JSON(201, c.MustGet(routix.ValidatedKey))
+	}))
+	for body, want := range map[string]int{`{"text":"hi"}`: 201, `{}`: 422, `{`: 400} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("POST", "/notes", body))
+		if w.Code != want {
+			t.Errorf("POST %s: status %d, want %d", body, w.Code, want)
+		}
+	}
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	const max, total = 2, 6
+	release := make(chan struct{})
+	entered := make(chan struct{}, total)
+	r := routix.New()
+	r.Use(routix.ConcurrencyLimit(max))
+	r.GET("/slow", func(c *routix.Context) error {
+		entered <- struct{}{}
+		<-release
+		return c.JSON(200, nil)
+	})
+
+	codes := make(chan *httptest.ResponseRecorder, total)
+	for i := 0; i < total; i++ {
+		go func() {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, newRequest("GET", "/slow", ""))
+			codes <- w
+		}()
+	}
+
+	// Once max handlers are running, every other request is shed.
+	for i := 0; i < max; i++ {
+		<-entered
+	}
+	for i := 0; i < total-max; i++ {
+		w := <-codes
+		if w.Code != 503 || w.Header().Get("Retry-After") == "" {
+			t.Errorf("expected 503 with Retry-After, got %d %v", w.Code, w.Header())
+		}
+	}
+	close(release)
+	for i := 0; i < max; i++ {
+		if w := <-codes; w.Code != 200 {
+			t.Errorf("admitted request: status %d", w.Code)
+		}
+	}
+	// Slots are released, so later requests get through.
+	entered = make(chan struct{}, 1)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/slow", ""))
+	if w.Code != 200 {
+		t.Errorf("after release: status %d", w.Code)
+	}
+
+	for _, bad := range []int{0, -1} {
+		func() {
+			defer func() {
+				if v := recover(); v == nil || !strings.Contains(fmt.Sprint(v), "at least 1") {
+					t.Errorf("ConcurrencyLimit(%d): expected a clear panic, got %v", bad, v)
+				}
+			}()
+			routix.ConcurrencyLimit(bad)
+		}()
+	}
+}
+
+func TestRouterValidate(t *testing.T) {
+	ok := func(c *routix.Context) error { return nil }
+
+	good := routix.New()
+	good.GET("/users/:id", ok)
+	good.POST("/users/:id", ok)
+	good.GET("/users/new", ok)
+	good.Static("/assets", t.TempDir())
+	if err := good.Validate(); err != nil {
+		t.Fatalf("unexpected problems: %v", err)
+	}
+
+	bad := routix.New()
+	bad.GET("/users/:id", ok)
+	bad.GET("/users/:name", ok)
+	bad.GET("/files/*/meta", ok)
+	bad.Use(nil)
+	bad.Static("/assets", filepath.Join(t.TempDir(), "missing"))
+	err := bad.Validate()
+	if err == nil {
+		t.Fatal("expected problems to be reported")
+	}
+	for _, want := range []string{"duplicate route GET /users/:name", "unreachable", "middleware 0 is nil", "missing"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %v", want, err)
+		}
+	}
+
+	if err := bad.ValidateOnStart().Start("127.0.0.1:-1"); err == nil || !strings.Contains(err.Error(), "duplicate route") {
+		t.Errorf("Start should refuse an invalid router, got %v", err)
+	}
+}
+
+func TestGroupMiddlewareShortCircuits(t *testing.T) {
+	var order []string
+	trace := func(name string, fail bool) routix.Middleware {
+		return func(next routix.Handler) routix.Handler {
+			return func(c *routix.Context) error {
+				order = append(order, name)
+				if fail && c.Request.Header.Get("X-API-Key") != "secret" {
+					return routix.Unauthorized("invalid API key", nil)
+				}
+				return next(c)
+			}
+		}
+	}
+
+	r := routix.New()
+	r.Use(trace("global", false))
+	api := r.Group("/api")
+	api.Use(trace("apikey", true), trace("audit", false))
+	ran := false
+	for _, register := range []func(string, routix.Handler, ...routix.Middleware) *routix.Route{api.GET, api.POST, api.PUT, api.DELETE, api.PATCH} {
+		register("/secret", func(c *routix.Context) error {
+			ran = true
+			return c.JSON(200, nil)
+		})
+	}
+
+	for _, method := range []string{"GET", "POST", "PUT", "DELETE", "PATCH"} {
+		order, ran = nil, false
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest(method, "/api/secret", ""))
+		if w.Code != 401 || ran {
+			t.Errorf("%s without key: status %d, handler ran %v", method, w.Code, ran)
+		}
+		if fmt.Sprint(order) != "[global apikey]" {
+			t.Errorf("%s without key: middleware ran %v", method, order)
+		}
+	}
+
+	order, ran = nil, false
+	req := newRequest("GET", "/api/secret", "")
+	req.Header.Set("X-API-Key", "secret")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 200 || !ran || fmt.Sprint(order) != "[global apikey audit]" {
+		t.Errorf("with key: status %d, ran %v, order %v", w.Code, ran, order)
+	}
+}
+
+func TestIntRangeHelpers(t *testing.T) {
+	type result struct {
+		Page  int    `json:"page"`
+		Limit int    `json:"limit"`
+		Err   string `json:"err"`
+	}
+	r := routix.New()
+	r.GET("/pages/:page", func(c *routix.Context) error {
+		var res result
+		page, err := c.ParamIntRange("page", 1, 100)
+		if err != nil {
+			if !errors.Is(err, strconv.ErrRange) && !strings.Contains(err.Error(), "invalid syntax") {
+				t.Errorf("unexpected error type: %v", err)
+			}
+			return err
+		}
+		res.Page = page
+		res.Limit = 20
+		if c.Query["limit"] != "" {
+			limit, err := c.QueryIntRange("limit", 1, 50)
+			if err != nil {
+				if !errors.Is(err, strconv.ErrRange) && !errors.Is(err, strconv.ErrSyntax) {
+					t.Errorf("unexpected query error: %v", err)
+				}
+				return err
+			}
+			res.Limit = limit
+		}
+		return c.JSON(200, res)
+	})
+
+	tests := []struct {
+		url    string
+		status int
+		want   string
+	}{
+		{"/pages/1", 200, `{"page":1,"limit":20,"err":""}`},
+		{"/pages/100?limit=50", 200, `{"page":100,"limit":50,"err":""}`},
+		{"/pages/0", 400, "must be between 1 and 100"},
+		{"/pages/101", 400, "must be between 1 and 100"},
+		{"/pages/abc", 400, "invalid path parameter"},
+		{"/pages/3?limit=0", 400, "limit: must be between 1 and 50"},
+		{"/pages/3?limit=51", 400, "limit: must be between 1 and 50"},
+		{"/pages/3?limit=ten", 400, "limit: must be an integer"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("GET", tt.url, ""))
+		if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("%s: got %d %s, want %d containing %q", tt.url, w.Code, w.Body.String(), tt.status, tt.want)
+		}
+	}
+}
+
+func TestNestedGroups(t *testing.T) {
+	var order []string
+	trace := func(name string) routix.Middleware {
+		return func(next routix.Handler) routix.Handler {
+			return func(c *routix.Context) error {
+				order = append(order, name)
+				return next(c)
+			}
+		}
+	}
+	ok := func(c *routix.Context) error { return c.JSON(200, nil) }
+
+	r := routix.New()
+	api := r.Group("/api").Use(trace("api"))
+	v1 := api.Group("/v1").Use(trace("v1"))
+	v1.GET("/users", ok)
+	v1.Group("").Use(trace("empty")).GET("/me", ok)
+	r.Group("").Group("/v2").GET("/users", ok)
+	api.GET("/status", ok)
+
+	tests := []struct {
+		path  string
+		order string
+	}{
+		{"/api/v1/users", "[api v1]"},
+		{"/api/v1/me", "[api v1 empty]"},
+		{"/v2/users", "[]"},
+		{"/api/status", "[api]"},
+	}
+	for _, tt := range tests {
+		order = nil
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("GET", tt.path, ""))
+		if w.Code != 200 {
+			t.Errorf("%s: status %d", tt.path, w.Code)
+		}
+		if fmt.Sprint(order) != tt.order {
+			t.Errorf("%s: middleware ran %v, want %s", tt.path, order, tt.order)
+		}
+	}
+}
+
+func TestPerRouteMiddleware(t *testing.T) {
+	var order []string
+	trace := func(name string) routix.Middleware {
+		return func(next routix.Handler) routix.Handler {
+			return func(c *routix.Context) error {
+				order = append(order, name)
+				return next(c)
+			}
+		}
+	}
+	adminOnly := func(next routix.Handler) routix.Handler {
+		return func(c *routix.Context) error {
+			if c.Request.Header.Get("X-Role") != "admin" {
+				return routix.Forbidden("admins only", nil)
+			}
+			return next(c)
+		}
+	}
+	ok := func(c *routix.Context) error { return c.JSON(200, nil) }
+
+	r := routix.New()
+	r.UseNamed("global", trace("global"))
+	r.GET("/admin", ok, trace("first"), adminOnly, trace("last"))
+	r.GET("/audit", ok, trace("inner")).UseNamed("audited", trace("outer"))
+	r.GET("/public", ok)
+	r.Group("/api").Use(trace("group")).POST("/items", ok, trace("route"))
+
+	req := newRequest("GET", "/admin", "")
+	req.Header.Set("X-Role", "admin")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 200 || fmt.Sprint(order) != "[global first last]" {
+		t.Errorf("admin: status %d, order %v", w.Code, order)
+	}
+
+	order = nil
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/admin", ""))
+	if w.Code != 403 || fmt.Sprint(order) != "[global first]" {
+		t.Errorf("non-admin: status %d, order %v", w.Code, order)
+	}
+
+	order = nil
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/public", ""))
+	if w.Code != 200 || fmt.Sprint(order) != "[global]" {
+		t.Errorf("public: status %d, order %v", w.Code, order)
+	}
+
+	order = nil
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("POST", "/api/items", ""))
+	if w.Code != 200 || fmt.Sprint(order) != "[global group route]" {
+		t.Errorf("group route: status %d, order %v", w.Code, order)
+	}
+
+	order = nil
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/audit", ""))
+	if w.Code != 200 || fmt.Sprint(order) != "[global outer inner]" {
+		t.Errorf("UseNamed route: status %d, order %v", w.Code, order)
+	}
+
+	if got := r.EffectiveMiddleware("GET", "/admin"); len(got) != 4 || got[0] != "global" {
+		t.Errorf("EffectiveMiddleware(/admin) = %v", got)
+	}
+	if got := r.EffectiveMiddleware("GET", "/audit"); fmt.Sprint(got) != "[global audited routix_test.TestPerRouteMiddleware]" {
+		t.Errorf("EffectiveMiddleware(/audit) = %v", got)
+	}
+}
+
+func TestAfterResponseHooks(t *testing.T) {
+	var seen []string
+	r := routix.New()
+	r.Use(routix.Recovery())
+	r.AfterResponse(func(c *routix.Context) {
+		seen = append(seen, fmt.Sprintf("%s %d", c.Request.URL.Path, c.StatusCode()))
+	})
+	r.AfterResponse(func(c *routix.Context) { panic("broken hook") })
+	r.AfterResponse(func(c *routix.Context) { seen = append(seen, "last") })
+	r.GET("/ok", func(c *routix.Context) error { return c.JSON(200, nil) })
+	r.GET("/panic", func(c *routix.Context) error { panic("boom") })
+
+	for _, path := range []string{"/ok", "/panic"} {
+		r.ServeHTTP(httptest.NewRecorder(), newRequest("GET", path, ""))
+	}
+	if want := "[/ok 200 last /panic 500 last]"; fmt.Sprint(seen) != want {
+		t.Errorf("hooks saw %v, want %s", seen, want)
+	}
+
+	// Hooks also follow a router-level PanicHandler.
+	seen = nil
+	p := routix.New().PanicHandler(func(c *routix.Context, v interface{}) {
+		c.JSON(503, nil)
+	})
+	p.AfterResponse(func(c *routix.Context) {
+		seen = append(seen, fmt.Sprintf("%d", c.StatusCode()))
+	})
+	p.GET("/panic", func(c *routix.Context) error { panic("boom") })
+	p.ServeHTTP(httptest.NewRecorder(), newRequest("GET", "/panic", ""))
+	if fmt.Sprint(seen) != "[503]" {
+		t.Errorf("hook after PanicHandler saw %v", seen)
+	}
+
+	// Errors that aren't *routix.Error are reported with the status sent.
+	seen = nil
+	e := routix.New()
+	e.AfterResponse(func(c *routix.Context) {
+		seen = append(seen, fmt.Sprintf("%d", c.StatusCode()))
+	})
+	e.GET("/fail", func(c *routix.Context) error { return errors.New("db down") })
+	e.GET("/items/:id", func(c *routix.Context) error {
+		_, err := c.ParamInt("id")
+		return err
+	})
+	var codes []int
+	for _, path := range []string{"/fail", "/items/abc"} {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, newRequest("GET", path, ""))
+		codes = append(codes, w.Code)
+	}
+	if fmt.Sprint(codes) != "[500 400]" || fmt.Sprint(seen) != "[500 400]" {
+		t.Errorf("sent %v, hooks saw %v", codes, seen)
+	}
+}
+
+func TestEnvelopeKeys(t *testing.T) {
+	r := routix.New().
+		EnvelopeDataKey("result").
+		EnvelopeStatusKey("outcome").
+		EnvelopeTimestampKey("at")
+	r.GET("/ok", func(c *routix.Context) error {
+		return c.Success(map[string]string{"data": "user payload keeps its keys"})
+	})
+	r.GET("/fail", func(c *routix.Context) error {
+		return c.Error(errors.New("nope"), "failed")
+	})
+
+	for _, path := range []string{"/ok", "/fail"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("GET", path, ""))
+		var body map[string]json.RawMessage
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range []string{"result", "outcome", "at"} {
+			if _, ok := body[key]; !ok {
+				t.Errorf("%s: missing %q in %s", path, key, w.Body.String())
+			}
+		}
+		for _, key := range []string{"data", "status", "timestamp"} {
+			if _, ok := body[key]; ok {
+				t.Errorf("%s: default key %q still present in %s", path, key, w.Body.String())
+			}
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/ok", ""))
+	if !strings.Contains(w.Body.String(), `"result":{"data":"user payload keeps its keys"}`) {
+		t.Errorf("payload renamed: %s", w.Body.String())
+	}
+
+	// Custom keys are written verbatim alongside a naming style.
+	s := routix.New().FieldNaming(routix.NamingSnake).EnvelopeDataKey("Payload")
+	s.GET("/page", func(c *routix.Context) error { return c.Paginated([]int{1}, 1, 3) })
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, newRequest("GET", "/page", ""))
+	if !strings.Contains(w.Body.String(), `"Payload":`) || !strings.Contains(w.Body.String(), `"status":`) {
+		t.Errorf("snake case with custom data key: %s", w.Body.String())
+	}
+}
+
+func TestCORSPreflightAllowedMethods(t *testing.T) {
+	ok := func(c *routix.Context) error { return c.JSON(200, nil) }
+	r := routix.New()
+	r.Use(routix.CORS())
+	r.GET("/reports/:id", ok)
+	r.GET("/items", ok)
+	r.POST("/items", ok)
+	r.DELETE("/items/:id", ok)
+
+	if got := r.AllowedMethods("/items"); fmt.Sprint(got) != "[GET POST]" {
+		t.Errorf("AllowedMethods(/items) = %v", got)
+	}
+
+	preflight := func(path string) *httptest.ResponseRecorder {
+		req := newRequest("OPTIONS", path, "")
+		req.Header.Set("Origin", "https://app.example")
+		req.Header.Set("Access-Control-Request-Method", "GET")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := preflight("/reports/7")
+	if w.Code != 200 {
+		t.Fatalf("preflight status %d", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Methods"); got != "GET, OPTIONS" {
+		t.Errorf("GET-only path advertises %q", got)
+	}
+	if got := preflight("/items").Header().Get("Access-Control-Allow-Methods"); got != "GET, POST, OPTIONS" {
+		t.Errorf("/items advertises %q", got)
+	}
+	if w := preflight("/nowhere"); w.Code == 200 {
+		t.Errorf("preflight for an unknown path should fail, got %d", w.Code)
+	}
+
+	// Without CORS, OPTIONS is still answered from the registered methods.
+	plain := routix.New()
+	plain.GET("/items", ok)
+	w = httptest.NewRecorder()
+	plain.ServeHTTP(w, newRequest("OPTIONS", "/items", ""))
+	if w.Code != 204 || w.Header().Get("Allow") != "GET, OPTIONS" {
+		t.Errorf("auto OPTIONS: %d Allow %q", w.Code, w.Header().Get("Allow"))
+	}
+}
+
+func TestContextValueStore(t *testing.T) {
+	r := routix.New()
+	r.Use(func(next routix.Handler) routix.Handler {
+		return func(c *routix.Context) error {
+			if c.Request.Header.Get("Authorization") == "Bearer ada" {
+				c.Set("user", "ada")
+				c.Set("user_id", 42)
+			}
+			return next(c)
+		}
+	})
+	r.GET("/me", func(c *routix.Context) error {
+		_, found := c.Get("user")
+		return c.JSON(200, map[string]any{
+			"user":     c.GetString("user"),
+			"id":       c.GetInt("user_id"),
+			"found":    found,
+			"mistyped": c.GetInt("user"),
+		})
+	})
+
+	req := newRequest("GET", "/me", "")
+	req.Header.Set("Authorization", "Bearer ada")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if got := strings.TrimSpace(w.Body.String()); got != `{"found":true,"id":42,"mistyped":0,"user":"ada"}` {
+		t.Errorf("authenticated: %s", got)
+	}
+
+	// The pooled context must not carry the previous request's values.
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/me", ""))
+	if got := strings.TrimSpace(w.Body.String()); got != `{"found":false,"id":0,"mistyped":0,"user":""}` {
+		t.Errorf("anonymous request saw stale values: %s", got)
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	r := routix.New()
+	r.GET("/slow", routix.WithTimeout(20*time.Millisecond, func(c *routix.Context) error {
+		select {
+		case <-c.Request.Context().Done():
+			return c.Request.Context().Err()
+		case <-time.After(time.Second):
+			return c.JSON(200, "too late")
+		}
+	}))
+	r.GET("/fast", routix.WithTimeout(time.Second, func(c *routix.Context) error {
+		return c.JSON(200, "done")
+	}))
+	r.GET("/late", routix.WithTimeout(20*time.Millisecond, func(c *routix.Context) error {
+		<-c.Request.Context().Done()
+		return c.JSON(200, "too late")
+	}))
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/slow", ""))
+	if w.Code != 503 {
+		t.Errorf("slow handler: status %d, want 503", w.Code)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("slow handler was not cancelled, took %v", elapsed)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/fast", ""))
+	if w.Code != 200 || strings.TrimSpace(w.Body.String()) != `"done"` {
+		t.Errorf("fast handler: %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("GET", "/late", ""))
+	if w.Code != 503 || strings.Contains(w.Body.String(), "too late") {
+		t.Errorf("write after the deadline: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestTypedParamHelpers(t *testing.T) {
+	c := routix.NewContext(httptest.NewRecorder(), newRequest("GET", "/", ""))
+	for k, v := range map[string]string{"id": "42", "big": "9000000000", "price": "9.99", "on": "true", "bad": "x1"} {
+		c.Params[k] = v
+	}
+
+	if n, err := c.ParamInt("id"); err != nil || n != 42 {
+		t.Errorf("ParamInt = %d, %v", n, err)
+	}
+	if n, err := c.ParamInt64("big"); err != nil || n != 9000000000 {
+		t.Errorf("ParamInt64 = %d, %v", n, err)
+	}
+	if f, err := c.ParamFloat("price"); err != nil || f != 9.99 {
+		t.Errorf("ParamFloat = %v, %v", f, err)
+	}
+	if b, err := c.ParamBool("on"); err != nil || !b {
+		t.Errorf("ParamBool = %v, %v", b, err)
+	}
+
+	_, err := c.ParamInt("missing")
+	var pe *routix.ParamError
+	if !errors.As(err, &pe) || !errors.Is(err, routix.ErrMissingParam) || err.Error() != "missing path parameter missing" {
+		t.Errorf("missing param: %v", err)
+	}
+	for name, parse := range map[string]func(string) error{
+		"int":   func(n string) error { _, err := c.ParamInt(n); return err },
+		"float": func(n string) error { _, err := c.ParamFloat(n); return err },
+		"bool":  func(n string) error { _, err := c.ParamBool(n); return err },
+	} {
+		err := parse("bad")
+		if !errors.As(err, &pe) || errors.Is(err, routix.ErrMissingParam) {
+			t.Errorf("%s: unparseable param should be a non-missing ParamError, got %v", name, err)
+		}
+		if routix.GetHTTPStatusCode(err) != 400 {
+			t.Errorf("%s: status %d, want 400", name, routix.GetHTTPStatusCode(err))
+		}
+	}
+
+	// Returned unchanged, a missing param answers 404 and a bad one 400.
+	r := routix.New()
+	handler := func(c *routix.Context) error {
+		_, err := c.ParamInt("id")
+		return err
+	}
+	r.GET("/items", handler)
+	r.GET("/items/:id", handler)
+	for path, want := range map[string]int{"/items": 404, "/items/x1": 400} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("GET", path, ""))
+		if w.Code != want {
+			t.Errorf("%s: status %d, want %d", path, w.Code, want)
+		}
+	}
+}
+
+func TestSecureJSON(t *testing.T) {
+	handler := func(c *routix.Context) error {
+		return c.SecureJSON(200, []map[string]int{{"id": 1}, {"id": 2}})
+	}
+	for prefix, r := range map[string]*routix.Router{
+		routix.DefaultSecureJSONPrefix: routix.New(),
+		"while(1);":                    routix.New().SecureJSONPrefix("while(1);"),
+	} {
+		r.GET("/items", handler)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("GET", "/items", ""))
+
+		body := w.Body.String()
+		if !strings.HasPrefix(body, prefix) {
+			t.Fatalf("body %q lacks prefix %q", body, prefix)
+		}
+		var items []map[string]int
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(body, prefix)), &items); err != nil || len(items) != 2 {
+			t.Errorf("rest of body is not the JSON array: %v %q", err, body)
+		}
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+			t.Errorf("Content-Type = %q", ct)
+		}
+	}
+}
+
+func TestCaptureMiddlewareSharesContextState(t *testing.T) {
+	for name, mw := range map[string]routix.Middleware{
+		"Cache":    routix.Cache(time.Minute),
+		"Compress": routix.Compress(),
+	} {
+		var seen any
+		var aborted bool
+		r := routix.New()
+		r.Use(func(next routix.Handler) routix.Handler {
+			return func(c *routix.Context) error {
+				err := next(c)
+				seen, _ = c.Get("user")
+				aborted = c.IsAborted()
+				return err
+			}
+		}, mw)
+		r.GET("/", func(c *routix.Context) error {
+			c.Set("user", "ada")
+			return c.AbortRedirect(302, "/login")
+		})
+
+		req := newRequest("GET", "/", "")
+		req.Header.Set("Accept-Encoding", "gzip")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		if seen != "ada" || !aborted {
+			t.Errorf("%s: outer middleware saw user=%v aborted=%v", name, seen, aborted)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Package routixtest provides helpers for unit-testing routix handlers
+// without building a Router.
+//
+//	c, w := routixtest.NewTestContext("POST", "/users", map[string]string{"name": "ada"})
+//	if err := createUser(c); err != nil {
+//		t.Fatal(err)
+//	}
+//	routixtest.AssertStatus(t, w, 201)
+package routixtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/ramusaaa/routix"
+)
+
+// NewTestContext returns a Context for a request with the given method and
+// path, plus the recorder capturing the response. A nil body sends none; a
+// string or []byte is sent as is and anything else is encoded as JSON.
+// Non-nil bodies are sent with a JSON Content-Type.
+func NewTestContext(method, path string, body interface{}) (*routix.Context, *httptest.ResponseRecorder) {
+	var r io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		r = bytes.NewBufferString(b)
+	case []byte:
+		r = bytes.NewBuffer(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			panic("routixtest: encoding body: " + err.Error())
+		}
+		r = bytes.NewBuffer(data)
+	}
+
+	req := httptest.NewRequest(method, path, r)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	return routix.NewContext(w, req), w
+}
+
+// AssertStatus fails the test unless the response has the given status.
+func AssertStatus(t testing.TB, w *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if w.Code != want {
+		t.Errorf("status: want %d, got %d (body %s)", want, w.Code, w.Body.String())
+	}
+}
+
+// AssertHeader fails the test unless the response header key equals want.
+func AssertHeader(t testing.TB, w *httptest.ResponseRecorder, key, want string) {
+	t.Helper()
+	if got := w.Header().Get(key); got != want {
+		t.Errorf("header %s: want %q, got %q", key, want, got)
+	}
+}
+
+// AssertJSON fails the test unless the response body is JSON equal to want.
+// want may be a JSON string or a value, which is encoded before comparing,
+// so key order and whitespace don't matter.
+func AssertJSON(t testing.TB, w *httptest.ResponseRecorder, want interface{}) {
+	t.Helper()
+	var wantJSON []byte
+	switch v := want.(type) {
+	case string:
+		wantJSON = []byte(v)
+	case []byte:
+		wantJSON = v
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("encoding expected JSON: %v", err)
+		}
+		wantJSON = data
+	}
+
+	var got, expected interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Errorf("body is not JSON: %v (body %s)", err, w.Body.String())
+		return
+	}
+	if err := json.Unmarshal(wantJSON, &expected); err != nil {
+		t.Fatalf("expected value is not JSON: %v", err)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("JSON body: want %s, got %s", wantJSON, bytes.TrimSpace(w.Body.Bytes()))
+	}
+}
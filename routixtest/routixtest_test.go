@@ -0,0 +1,58 @@
+package routixtest_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ramusaaa/routix"
+	"github.com/ramusaaa/routix/routixtest"
+)
+
+func createUser(c *routix.Context) error {
+	var in struct {
+		Name string `json:"name"`
+	}
+	if err := c.Bind(&in); err != nil {
+		return err
+	}
+	c.SetHeader("Location", "/users/"+c.Params["org"]+"/"+in.Name)
+	return c.JSON(201, map[string]any{"name": in.Name, "org": c.Params["org"], "notify": c.Query["notify"]})
+}
+
+func TestHarness(t *testing.T) {
+	c, w := routixtest.NewTestContext("POST", "/orgs/acme/users?notify=yes", map[string]string{"name": "ada"})
+	c.Params["org"] = "acme"
+
+	if err := createUser(c); err != nil {
+		t.Fatal(err)
+	}
+	routixtest.AssertStatus(t, w, 201)
+	routixtest.AssertHeader(t, w, "Location", "/users/acme/ada")
+	routixtest.AssertJSON(t, w, `{"org":"acme","name":"ada","notify":"yes"}`)
+	routixtest.AssertJSON(t, w, map[string]string{"name": "ada", "org": "acme", "notify": "yes"})
+}
+
+// recordingTB captures failures so the assertions themselves can be tested.
+type recordingTB struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestAssertionsReportMismatches(t *testing.T) {
+	c, w := routixtest.NewTestContext("GET", "/", nil)
+	c.JSON(200, map[string]int{"n": 1})
+
+	tb := &recordingTB{TB: t}
+	routixtest.AssertStatus(tb, w, 404)
+	routixtest.AssertHeader(tb, w, "X-Missing", "x")
+	routixtest.AssertJSON(tb, w, `{"n":2}`)
+	if len(tb.failures) != 3 {
+		t.Errorf("expected 3 failures, got %v", tb.failures)
+	}
+}
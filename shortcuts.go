@@ -1,7 +1,14 @@
 package routix
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
 	"net/http"
+	"os"
+	"regexp"
+	"strings"
 	"time"
 )
 
@@ -41,6 +48,19 @@ func (rt *RouteBuilder) JSON(data interface{}) *Router {
 	})
 }
 
+// JSONStatus is a shortcut for JSON responses with the given status, for
+// writes answering 201 or 204. Statuses that carry no body, such as 204 and
+// 304, send just the status and ignore data.
+func (rt *RouteBuilder) JSONStatus(code int, data interface{}) *Router {
+	return rt.Handle(func(c *Context) error {
+		if code == http.StatusNoContent || code == http.StatusNotModified {
+			c.Response.WriteHeader(code)
+			return nil
+		}
+		return c.JSON(code, data)
+	})
+}
+
 // Text is a shortcut for text responses
 func (rt *RouteBuilder) Text(text string) *Router {
 	return rt.Handle(func(c *Context) error {
@@ -57,14 +77,86 @@ func (rt *RouteBuilder) Redirect(url string) *Router {
 
 // Static serves static files
 func (r *Router) Static(path, dir string) *Router {
-	fileServer := http.FileServer(http.Dir(dir))
+	return r.StaticWithOptions(path, dir, StaticOptions{})
+}
+
+// StaticOptions configures caching for StaticWithOptions. Last-Modified is
+// always sent.
+type StaticOptions struct {
+	// MaxAge is the Cache-Control max-age for files without a fingerprint.
+	// Zero sends no Cache-Control header.
+	MaxAge time.Duration
+	// Immutable serves fingerprinted files, such as app.3f2a9c1e.js, with
+	// a one year max-age and the immutable directive.
+	Immutable bool
+	// ETag adds a weak ETag derived from the file size and modification time.
+	ETag bool
+}
+
+// fingerprintPattern matches a content hash segment in a file name, as in
+// app.3f2a9c1e.js or logo-8d3e7f21a0.png.
+var fingerprintPattern = regexp.MustCompile(`[.-][0-9a-fA-F]{8,}\.[^.]+$`)
+
+// StaticWithOptions serves static files from dir with the given cache headers.
+func (r *Router) StaticWithOptions(path, dir string, opts StaticOptions) *Router {
+	r.mu.Lock()
+	r.staticDirs = append(r.staticDirs, dir)
+	r.mu.Unlock()
+
+	fileServer := http.StripPrefix(path, http.FileServer(http.Dir(dir)))
 	r.GET(path+"/*", func(c *Context) error {
-		http.StripPrefix(path, fileServer).ServeHTTP(c.Response, c.Request)
+		file, err := c.SafePath(dir)
+		if err != nil {
+			return BadRequest("invalid path", err)
+		}
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			h := c.Response.Header()
+			if opts.Immutable && fingerprintPattern.MatchString(info.Name()) {
+				h.Set("Cache-Control", "public, max-age=31536000, immutable")
+			} else if opts.MaxAge > 0 {
+				h.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(opts.MaxAge.Seconds())))
+			}
+			if opts.ETag {
+				h.Set("ETag", fmt.Sprintf(`W/"%x-%x"`, info.Size(), info.ModTime().UnixNano()))
+			}
+		}
+		fileServer.ServeHTTP(c.Response, c.Request)
 		return nil
 	})
 	return r
 }
 
+// ServeFile registers GET and HEAD routes at path that serve a single file,
+// such as /favicon.ico or /robots.txt. The content type comes from the
+// file's extension; conditional and range requests are supported.
+func (r *Router) ServeFile(path, file string) *Router {
+	handler := func(c *Context) error {
+		http.ServeFile(c.Response, c.Request, file)
+		return nil
+	}
+	r.GET(path, handler)
+	r.HEAD(path, handler)
+	return r
+}
+
+// ServeContent registers GET and HEAD routes at path that serve content
+// from memory, with Range and If-Modified-Since support. content is read
+// once at registration, so concurrent requests never share a reader. The
+// content type comes from path's extension, else from sniffing the data.
+func (r *Router) ServeContent(path string, modtime time.Time, content io.ReadSeeker) *Router {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		panic(fmt.Sprintf("routix: reading content for %s: %v", path, err))
+	}
+	handler := func(c *Context) error {
+		http.ServeContent(c.Response, c.Request, path, modtime, bytes.NewReader(data))
+		return nil
+	}
+	r.GET(path, handler)
+	r.HEAD(path, handler)
+	return r
+}
+
 // API creates an API group with JSON middleware
 func (r *Router) API(path string) *Group {
 	group := r.Group(path)
@@ -77,36 +169,82 @@ func (r *Router) API(path string) *Group {
 	return group
 }
 
-// Resource creates RESTful routes for a resource
+// Resource creates RESTful routes for a resource. Besides the controller's
+// handlers it registers HEAD for the index and show routes and OPTIONS
+// reporting the allowed methods in an Allow header.
 func (r *Router) Resource(path string, controller ResourceController) *Router {
+	collection := []string{}
+	item := []string{}
+
 	// GET /resource - index
 	if controller.Index != nil {
 		r.GET(path, controller.Index)
+		r.HEAD(path, headHandler(controller.Index))
+		collection = append(collection, http.MethodGet, http.MethodHead)
 	}
 	
 	// POST /resource - create
 	if controller.Create != nil {
 		r.POST(path, controller.Create)
+		collection = append(collection, http.MethodPost)
 	}
 	
 	// GET /resource/:id - show
 	if controller.Show != nil {
 		r.GET(path+"/:id", controller.Show)
+		r.HEAD(path+"/:id", headHandler(controller.Show))
+		item = append(item, http.MethodGet, http.MethodHead)
 	}
 	
 	// PUT /resource/:id - update
 	if controller.Update != nil {
 		r.PUT(path+"/:id", controller.Update)
+		item = append(item, http.MethodPut)
 	}
 	
 	// DELETE /resource/:id - delete
 	if controller.Delete != nil {
 		r.DELETE(path+"/:id", controller.Delete)
+		item = append(item, http.MethodDelete)
+	}
+
+	// OPTIONS /resource and /resource/:id - allowed methods
+	if len(collection) > 0 {
+		r.OPTIONS(path, allowHandler(collection))
+	}
+	if len(item) > 0 {
+		r.OPTIONS(path+"/:id", allowHandler(item))
 	}
 	
 	return r
 }
 
+// allowHandler answers OPTIONS with the given methods in the Allow header.
+func allowHandler(methods []string) Handler {
+	allow := strings.Join(append(append([]string{}, methods...), http.MethodOptions), ", ")
+	return func(c *Context) error {
+		c.SetHeader("Allow", allow)
+		return c.NoContent()
+	}
+}
+
+// headHandler runs a GET handler for HEAD requests, keeping its status and
+// headers but dropping the body.
+func headHandler(get Handler) Handler {
+	return func(c *Context) error {
+		w := c.Response
+		c.Response = bodylessWriter{w}
+		defer func() { c.Response = w }()
+		return get(c)
+	}
+}
+
+type bodylessWriter struct {
+	http.ResponseWriter
+}
+
+func (w bodylessWriter) Write(p []byte) (int, error) { return len(p), nil }
+
 // ResourceController defines the interface for RESTful controllers
 type ResourceController struct {
 	Index  Handler
@@ -131,10 +269,54 @@ func (r *Router) WithCache(duration string) *Router {
 	return r.Use(Cache(parseDuration(duration)))
 }
 
+// WithTimeout adds the Timeout middleware to every route. Not to be
+// confused with the package-level WithTimeout, which bounds one handler;
+// HandleTimeout registers a route with it.
 func (r *Router) WithTimeout(duration string) *Router {
 	return r.Use(Timeout(parseDuration(duration)))
 }
 
+// HandleTimeout registers a route whose handler alone is bound by the given
+// timeout, leaving other routes unaffected. See the package-level
+// WithTimeout: the handler should watch c.Request.Context(), and an overrun
+// is answered with 503. Middleware runs as with Handle, outside the timeout.
+func (r *Router) HandleTimeout(method, path string, timeout time.Duration, handler Handler, middleware ...Middleware) *Route {
+	return r.Handle(method, path, WithTimeout(timeout, handler), middleware...)
+}
+
+// GETTimeout registers a GET route with its own timeout.
+func (r *Router) GETTimeout(path string, timeout time.Duration, handler Handler, middleware ...Middleware) *Route {
+	return r.HandleTimeout(http.MethodGet, path, timeout, handler, middleware...)
+}
+
+// LongPollInterval is how long LongPoll waits before calling fetch again
+// after it reports no data.
+var LongPollInterval = 100 * time.Millisecond
+
+// LongPoll returns a handler that holds the request open until fetch
+// reports data, then writes it as a success response. fetch receives a
+// context that ends with the timeout or when the client goes away; it may
+// block on it or return false straight away to be polled again. When the
+// timeout elapses first the response is 204 No Content, telling the client
+// to poll again.
+func LongPoll(timeout time.Duration, fetch func(ctx context.Context) (interface{}, bool)) Handler {
+	return func(c *Context) error {
+		ctx, cancel := context.WithTimeout(c.RequestContext(), timeout)
+		defer cancel()
+
+		for {
+			if data, ok := fetch(ctx); ok {
+				return c.Success(data)
+			}
+			select {
+			case <-ctx.Done():
+				return c.NoContent()
+			case <-time.After(LongPollInterval):
+			}
+		}
+	}
+}
+
 func parseDuration(duration string) time.Duration {
 	d, err := time.ParseDuration(duration)
 	if err != nil {
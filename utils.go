@@ -5,6 +5,8 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"mime"
+	"net"
 	"net/http"
 	"reflect"
 	"strconv"
@@ -75,6 +77,16 @@ func GetContentType(req *http.Request) string {
 	return req.Header.Get("Content-Type")
 }
 
+// mediaType returns the lower-cased media type of a Content-Type header
+// value with parameters such as charset stripped, or "" if it is malformed.
+func mediaType(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return mt
+}
+
 func IsJSONRequest(req *http.Request) bool {
 	contentType := GetContentType(req)
 	return strings.Contains(contentType, "application/json")
@@ -86,6 +98,63 @@ func IsFormRequest(req *http.Request) bool {
 		strings.Contains(contentType, "multipart/form-data")
 }
 
+// Trusted platforms understood by Router.TrustedPlatform.
+const (
+	PlatformCloudflare = "cloudflare" // CF-Connecting-IP
+	PlatformGCP        = "gcp"        // X-Appengine-User-Ip
+	PlatformAWS        = "aws"        // CloudFront-Viewer-Address
+	PlatformFly        = "fly"        // Fly-Client-IP
+)
+
+// PlatformIP reads the client IP from the header set by the given hosting
+// platform. Unknown platform names are treated as a header name. It reports
+// false when the header is absent.
+func PlatformIP(req *http.Request, platform string) (string, bool) {
+	var ip string
+	switch platform {
+	case "":
+		return "", false
+	case PlatformCloudflare:
+		ip = req.Header.Get("CF-Connecting-IP")
+	case PlatformGCP:
+		ip = req.Header.Get("X-Appengine-User-Ip")
+	case PlatformAWS:
+		// CloudFront sends ip:port.
+		ip = req.Header.Get("CloudFront-Viewer-Address")
+		if i := strings.LastIndex(ip, ":"); i != -1 {
+			ip = strings.Trim(ip[:i], "[]")
+		}
+	case PlatformFly:
+		ip = req.Header.Get("Fly-Client-IP")
+	default:
+		ip = req.Header.Get(platform)
+	}
+	ip = strings.TrimSpace(ip)
+	return ip, ip != ""
+}
+
+// GetRealIPFor is GetRealIP for apps behind a known platform: the platform
+// header is trusted directly and X-Forwarded-For is not consulted. Without
+// the header it falls back to the host part of RemoteAddr. An empty
+// platform behaves like GetRealIP.
+func GetRealIPFor(req *http.Request, platform string) string {
+	if platform == "" {
+		return GetRealIP(req)
+	}
+	if ip, ok := PlatformIP(req, platform); ok {
+		return ip
+	}
+	return remoteHost(req)
+}
+
+// remoteHost returns RemoteAddr without its port.
+func remoteHost(req *http.Request) string {
+	if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		return host
+	}
+	return req.RemoteAddr
+}
+
 func GetRealIP(req *http.Request) string {
 	if xff := req.Header.Get("X-Forwarded-For"); xff != "" {
 		ips := strings.Split(xff, ",")
@@ -100,7 +169,7 @@ func GetRealIP(req *http.Request) string {
 		return cf
 	}
 	
-	return req.RemoteAddr
+	return remoteHost(req)
 }
 
 func ParseInt(s string, defaultValue int) int {
@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -30,29 +31,102 @@ func (v *Validator) Validate(obj interface{}) bool {
 		return false
 	}
 
-	typ := val.Type()
-	for i := 0; i < val.NumField(); i++ {
-		field := val.Field(i)
-		fieldType := typ.Field(i)
-		tag := fieldType.Tag.Get("validate")
+	for _, f := range structRules(val.Type()) {
+		field := val.Field(f.index)
+		for _, rule := range f.rules {
+			if err := v.validateField(field, f.name, rule); err != nil {
+				v.errors = append(v.errors, *err)
+			}
+		}
+	}
+
+	v.validateStruct(obj, val)
+
+	return len(v.errors) == 0
+}
 
+// fieldRules are the validate rules of one struct field.
+type fieldRules struct {
+	index int
+	name  string
+	rules []string
+}
+
+// ruleCache maps a struct reflect.Type to its []fieldRules, so tags are
+// parsed once per type rather than on every Validate.
+var ruleCache sync.Map
+
+func structRules(typ reflect.Type) []fieldRules {
+	if cached, ok := ruleCache.Load(typ); ok {
+		return cached.([]fieldRules)
+	}
+	var fields []fieldRules
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		tag := sf.Tag.Get("validate")
 		if tag == "" {
 			continue
 		}
+		fields = append(fields, fieldRules{index: i, name: jsonFieldName(sf), rules: strings.Split(tag, ",")})
+	}
+	cached, _ := ruleCache.LoadOrStore(typ, fields)
+	return cached.([]fieldRules)
+}
 
-		rules := strings.Split(tag, ",")
-		for _, rule := range rules {
-			if err := v.validateField(field, fieldType.Name, rule); err != nil {
-				v.errors = append(v.errors, *err)
-			}
-		}
+// jsonFieldName is the name a field has in JSON payloads, so validation
+// errors name fields the way API clients see them.
+func jsonFieldName(sf reflect.StructField) string {
+	if name, _, _ := strings.Cut(sf.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
 	}
+	return sf.Name
+}
 
-	return len(v.errors) == 0
+// Validatable is implemented by types with checks that struct tags can't
+// express, such as rules spanning several fields. Validator calls Validate
+// after the field-level checks and merges what it returns: ValidationErrors
+// and *ValidationError are kept as-is, other errors are recorded without a
+// field.
+type Validatable interface {
+	Validate() error
+}
+
+func (v *Validator) validateStruct(obj interface{}, val reflect.Value) {
+	validatable, ok := obj.(Validatable)
+	if !ok && val.CanAddr() {
+		validatable, ok = val.Addr().Interface().(Validatable)
+	}
+	if !ok {
+		return
+	}
+
+	switch err := validatable.Validate().(type) {
+	case nil:
+	case ValidationErrors:
+		for _, e := range err {
+			v.errors = append(v.errors, *e)
+		}
+	case *ValidationError:
+		v.errors = append(v.errors, *err)
+	default:
+		v.errors = append(v.errors, *NewValidationError("", err.Error()))
+	}
 }
 
 // validateField validates a single field based on the given rule
 func (v *Validator) validateField(field reflect.Value, fieldName, rule string) *ValidationError {
+	switch field.Type() {
+	case timeType:
+		if !field.CanInterface() {
+			return nil
+		}
+		return validateTimeField(field.Interface().(time.Time), fieldName, rule)
+	case durationType:
+		if err, ok := validateDurationField(time.Duration(field.Int()), fieldName, rule); ok {
+			return err
+		}
+	}
+
 	switch {
 	case rule == "required":
 		if isEmpty(field) {
@@ -97,6 +171,77 @@ func (v *Validator) validateField(field reflect.Value, fieldName, rule string) *
 	return nil
 }
 
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// validateTimeField applies required, after= and before= to a time.Time.
+// Bounds are "now", an RFC 3339 timestamp or a 2006-01-02 date. Other
+// rules don't apply to times and are ignored.
+func validateTimeField(t time.Time, fieldName, rule string) *ValidationError {
+	switch {
+	case rule == "required":
+		if t.IsZero() {
+			return NewValidationError(fieldName, "field is required")
+		}
+	case strings.HasPrefix(rule, "after="):
+		bound, ok := parseTimeBound(rule[6:])
+		if !ok {
+			return NewValidationError(fieldName, fmt.Sprintf("invalid time bound: %s", rule[6:]))
+		}
+		if !t.After(bound) {
+			return NewValidationError(fieldName, fmt.Sprintf("must be after %s", rule[6:]))
+		}
+	case strings.HasPrefix(rule, "before="):
+		bound, ok := parseTimeBound(rule[7:])
+		if !ok {
+			return NewValidationError(fieldName, fmt.Sprintf("invalid time bound: %s", rule[7:]))
+		}
+		if !t.Before(bound) {
+			return NewValidationError(fieldName, fmt.Sprintf("must be before %s", rule[7:]))
+		}
+	}
+	return nil
+}
+
+func parseTimeBound(s string) (time.Time, bool) {
+	if s == "now" {
+		return time.Now(), true
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// validateDurationField applies min= and max= bounds written as durations
+// (min=1s,max=1h) to a time.Duration. It reports false for rules it doesn't
+// handle, such as plain numeric bounds, so the generic checks run instead.
+func validateDurationField(d time.Duration, fieldName, rule string) (*ValidationError, bool) {
+	var bound string
+	switch {
+	case strings.HasPrefix(rule, "min="), strings.HasPrefix(rule, "max="):
+		bound = rule[4:]
+	default:
+		return nil, false
+	}
+	limit, err := time.ParseDuration(bound)
+	if err != nil {
+		return nil, false
+	}
+	if strings.HasPrefix(rule, "min=") && d < limit {
+		return NewValidationError(fieldName, fmt.Sprintf("duration must be at least %v", limit)), true
+	}
+	if strings.HasPrefix(rule, "max=") && d > limit {
+		return NewValidationError(fieldName, fmt.Sprintf("duration must be at most %v", limit)), true
+	}
+	return nil, true
+}
+
 // isEmpty checks if a field is empty
 func isEmpty(field reflect.Value) bool {
 	switch field.Kind() {
@@ -166,10 +311,11 @@ func validateEmail(field reflect.Value) bool {
 	if field.Kind() != reflect.String {
 		return false
 	}
-	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
 	return emailRegex.MatchString(field.String())
 }
 
+var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+
 // validateRegex validates against a regular expression
 func validateRegex(field reflect.Value, pattern string) bool {
 	if field.Kind() != reflect.String {
@@ -0,0 +1,143 @@
+package routix
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// APIVersionKey is the context key holding the version a request was
+// dispatched to by AcceptVersioning.
+const APIVersionKey = "routix.api_version"
+
+// AcceptVersioning dispatches one path to different handlers based on a
+// vendor media type in the Accept header, e.g.
+// Accept: application/vnd.myapp.v2+json. Requests without a recognised
+// version, or asking for one the route doesn't have, get the latest version.
+type AcceptVersioning struct {
+	router *Router
+	vendor string
+	mu     sync.Mutex
+	routes map[string]*versionedRoute
+}
+
+type versionedRoute struct {
+	mu       sync.RWMutex
+	handlers map[int]Handler
+	latest   int
+	route    *Route // the router route dispatching to every version
+}
+
+// VersionByAccept enables Accept-header versioning for the given vendor name.
+//
+//	v := r.VersionByAccept("myapp")
+//	v.Version(1).GET("/users", listUsersV1)
+//	v.Version(2).GET("/users", listUsersV2)
+func (r *Router) VersionByAccept(vendor string) *AcceptVersioning {
+	return &AcceptVersioning{
+		router: r,
+		vendor: vendor,
+		routes: make(map[string]*versionedRoute),
+	}
+}
+
+// Version returns a registrar for routes served under the given version.
+func (av *AcceptVersioning) Version(version int) *VersionedRoutes {
+	return &VersionedRoutes{av: av, version: version}
+}
+
+// VersionedRoutes registers handlers for a single API version.
+type VersionedRoutes struct {
+	av      *AcceptVersioning
+	version int
+}
+
+// Handle registers handler for this version. Middleware passed after it
+// wraps this version's handler only. The returned Route is shared by every
+// version of method and path, so schemas, Cache and RateLimit set on it
+// apply to all of them.
+func (vr *VersionedRoutes) Handle(method, path string, handler Handler, middleware ...Middleware) *Route {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](abortable(handler))
+	}
+	av := vr.av
+	key := method + " " + path
+
+	av.mu.Lock()
+	route, ok := av.routes[key]
+	if !ok {
+		route = &versionedRoute{handlers: make(map[int]Handler)}
+		av.routes[key] = route
+	}
+	av.mu.Unlock()
+
+	route.mu.Lock()
+	route.handlers[vr.version] = handler
+	if len(route.handlers) == 1 || vr.version > route.latest {
+		route.latest = vr.version
+	}
+	route.mu.Unlock()
+
+	if !ok {
+		rt := av.router.Handle(method, path, av.dispatch(route))
+		route.mu.Lock()
+		route.route = rt
+		route.mu.Unlock()
+	}
+	route.mu.RLock()
+	defer route.mu.RUnlock()
+	return route.route
+}
+
+func (vr *VersionedRoutes) GET(path string, handler Handler, middleware ...Middleware) *Route {
+	return vr.Handle(http.MethodGet, path, handler, middleware...)
+}
+func (vr *VersionedRoutes) POST(path string, handler Handler, middleware ...Middleware) *Route {
+	return vr.Handle(http.MethodPost, path, handler, middleware...)
+}
+func (vr *VersionedRoutes) PUT(path string, handler Handler, middleware ...Middleware) *Route {
+	return vr.Handle(http.MethodPut, path, handler, middleware...)
+}
+func (vr *VersionedRoutes) DELETE(path string, handler Handler, middleware ...Middleware) *Route {
+	return vr.Handle(http.MethodDelete, path, handler, middleware...)
+}
+func (vr *VersionedRoutes) PATCH(path string, handler Handler, middleware ...Middleware) *Route {
+	return vr.Handle(http.MethodPatch, path, handler, middleware...)
+}
+
+func (av *AcceptVersioning) dispatch(route *versionedRoute) Handler {
+	return func(c *Context) error {
+		route.mu.RLock()
+		version := route.latest
+		if requested, ok := av.requestedVersion(c.Request.Header.Get("Accept")); ok {
+			if _, exists := route.handlers[requested]; exists {
+				version = requested
+			}
+		}
+		handler := route.handlers[version]
+		route.mu.RUnlock()
+
+		c.Set(APIVersionKey, version)
+		return handler(c)
+	}
+}
+
+// requestedVersion extracts N from application/vnd.<vendor>.vN[+suffix].
+func (av *AcceptVersioning) requestedVersion(accept string) (int, bool) {
+	prefix := "application/vnd." + strings.ToLower(av.vendor) + ".v"
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType := strings.ToLower(strings.TrimSpace(strings.SplitN(mediaRange, ";", 2)[0]))
+		if !strings.HasPrefix(mediaType, prefix) {
+			continue
+		}
+		token := strings.TrimPrefix(mediaType, prefix)
+		if i := strings.Index(token, "+"); i != -1 {
+			token = token[:i]
+		}
+		if version, err := strconv.Atoi(token); err == nil {
+			return version, true
+		}
+	}
+	return 0, false
+}